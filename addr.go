@@ -0,0 +1,446 @@
+// Copyright 2020 Hu Jun. All rights reserved.
+// This project is licensed under the terms of the MIT license.
+// license that can be found in the LICENSE file.
+
+package myaddr
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"math/bits"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// CompareAddrs return -1 if a < b, 0 if a == b, 1 if a > b, using following order:
+//  1. invalid (zero) address is smaller than any valid address
+//  2. IPv4 address is smaller than any IPv6 address, IPv4-mapped IPv6 address is IPv6
+//  3. addresses in same family are compared numerically
+//  4. numerically equal addresses are compared by zone, in lexical order,
+//     an address without zone is smaller than one with zone
+func CompareAddrs(a, b netip.Addr) int {
+	return a.Compare(b)
+}
+
+// SortAddrs sort addrs in ascending order in place, see CompareAddrs for the order
+func SortAddrs(addrs []netip.Addr) {
+	sort.SliceStable(addrs, func(i, j int) bool {
+		return CompareAddrs(addrs[i], addrs[j]) < 0
+	})
+}
+
+// AddrRangeT is an address range from Start to End (inclusive),
+// its JSON form is a string like "192.168.1.1-192.168.1.100"
+type AddrRangeT struct {
+	Start, End netip.Addr
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (r AddrRangeT) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Start.String() + "-" + r.End.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface,
+// Start and End must be in same address family, and Start <= End;
+// zone could contain "-", e.g. "fe80::1%eth-0-fe80::2%eth-0",
+// return error if the range could be split into Start and End in more than one way
+func (r *AddrRangeT) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if !strings.Contains(s, "-") {
+		return fmt.Errorf("%v is not a valid address range", s)
+	}
+	var start, end netip.Addr
+	var err error
+	found := false
+	//address itself doesn't contain "-", try every "-" in case zone contains it
+	for i := 0; i < len(s); i++ {
+		if s[i] != '-' {
+			continue
+		}
+		a, aerr := netip.ParseAddr(s[:i])
+		z, zerr := netip.ParseAddr(s[i+1:])
+		switch {
+		case aerr == nil && zerr == nil:
+			if found {
+				return fmt.Errorf("%v is an ambiguous address range", s)
+			}
+			start, end, found = a, z, true
+		case err == nil && aerr != nil:
+			err = fmt.Errorf("invalid start address in %v, %w", s, aerr)
+		case err == nil:
+			err = fmt.Errorf("invalid end address in %v, %w", s, zerr)
+		}
+	}
+	if !found {
+		return err
+	}
+	if err := sameFamily(start, end); err != nil {
+		return err
+	}
+	if start.Compare(end) > 0 {
+		return fmt.Errorf("start %v is bigger than end %v", start, end)
+	}
+	r.Start = start
+	r.End = end
+	return nil
+}
+
+// AddrInRange return true if low <= addr <= high, zone is ignored;
+// addr, low and high must be in same address family, and low <= high
+func AddrInRange(addr, low, high netip.Addr) (bool, error) {
+	if err := sameFamily(low, high); err != nil {
+		return false, err
+	}
+	if err := sameFamily(addr, low); err != nil {
+		return false, err
+	}
+	addr, low, high = addr.WithZone(""), low.WithZone(""), high.WithZone("")
+	if low.Compare(high) > 0 {
+		return false, fmt.Errorf("low %v is bigger than high %v", low, high)
+	}
+	return low.Compare(addr) <= 0 && addr.Compare(high) <= 0, nil
+}
+
+// IncAddrKeepZone increase addr by step (could be negative), return the result,
+// which is in same address family and has same zone as addr
+func IncAddrKeepZone(addr netip.Addr, step *big.Int) (netip.Addr, error) {
+	if !addr.IsValid() {
+		return netip.Addr{}, fmt.Errorf("invalid address %v", addr)
+	}
+	rn := new(big.Int).Add(netipToBig(addr), step)
+	if rn.Sign() < 0 {
+		return netip.Addr{}, fmt.Errorf("%v and step %d result in negative result", addr, step)
+	}
+	r, err := bigToNetip(rn, addr.BitLen())
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("%v and step %d result exceeds max address", addr, step)
+	}
+	return r.WithZone(addr.Zone()), nil
+}
+
+// ApplyMask return the result of bitwise AND of addr and mask, mask could be non-contiguous;
+// addr and mask must be in same address family, zone of addr is kept in the result
+func ApplyMask(addr, mask netip.Addr) (netip.Addr, error) {
+	if err := sameFamily(addr, mask); err != nil {
+		return netip.Addr{}, err
+	}
+	a := addr.AsSlice()
+	m := mask.AsSlice()
+	for i := range a {
+		a[i] &= m[i]
+	}
+	r, _ := netip.AddrFromSlice(a)
+	return r.WithZone(addr.Zone()), nil
+}
+
+// ExpandIPv6 return the fully expanded form of IPv6 addr,
+// like 2001:0db8:0000:0000:0000:0000:0000:0001
+func ExpandIPv6(addr netip.Addr) (string, error) {
+	if !addr.Is6() {
+		return "", fmt.Errorf("%v is not an IPv6 address", addr)
+	}
+	return addr.StringExpanded(), nil
+}
+
+// CompressIPv6 parse IPv6 address string s, which could be in expanded or compressed form,
+// the String() of the result is the compressed form
+func CompressIPv6(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if !addr.Is6() {
+		return netip.Addr{}, fmt.Errorf("%v is not an IPv6 address", s)
+	}
+	return addr, nil
+}
+
+// AllNodesMulticast return IPv6 link-local all-nodes multicast address ff02::1
+func AllNodesMulticast() netip.Addr {
+	return netip.AddrFrom16([16]byte{0xff, 0x02, 15: 0x01})
+}
+
+// AllRoutersMulticast return IPv6 link-local all-routers multicast address ff02::2
+func AllRoutersMulticast() netip.Addr {
+	return netip.AddrFrom16([16]byte{0xff, 0x02, 15: 0x02})
+}
+
+// AllHostsMulticastIPv4 return IPv4 all-hosts (all systems) multicast address 224.0.0.1
+func AllHostsMulticastIPv4() netip.Addr {
+	return netip.AddrFrom4([4]byte{224, 0, 0, 1})
+}
+
+// AllRoutersMulticastIPv4 return IPv4 all-routers multicast address 224.0.0.2
+func AllRoutersMulticastIPv4() netip.Addr {
+	return netip.AddrFrom4([4]byte{224, 0, 0, 2})
+}
+
+// ZeroAddr return IPv4 zero address 0.0.0.0 if ipv4 is true, IPv6 zero address :: otherwise
+func ZeroAddr(ipv4 bool) netip.Addr {
+	if ipv4 {
+		return netip.IPv4Unspecified()
+	}
+	return netip.IPv6Unspecified()
+}
+
+// MaxAddr return IPv4 max address 255.255.255.255 if ipv4 is true,
+// IPv6 max address ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff otherwise
+func MaxAddr(ipv4 bool) netip.Addr {
+	if ipv4 {
+		return netip.AddrFrom4([4]byte{255, 255, 255, 255})
+	}
+	return netip.AddrFrom16([16]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+}
+
+// MatchingPrefixBits return number of leading bits a and b share,
+// a and b must be in same address family
+func MatchingPrefixBits(a, b netip.Addr) (int, error) {
+	if err := sameFamily(a, b); err != nil {
+		return 0, err
+	}
+	ab := a.AsSlice()
+	bb := b.AsSlice()
+	r := 0
+	for i := range ab {
+		x := ab[i] ^ bb[i]
+		r += bits.LeadingZeros8(x)
+		if x != 0 {
+			break
+		}
+	}
+	return r, nil
+}
+
+// HammingDistance return number of bits that differ between a and b,
+// a and b must be in same address family
+func HammingDistance(a, b netip.Addr) (int, error) {
+	if err := sameFamily(a, b); err != nil {
+		return 0, err
+	}
+	ab := a.AsSlice()
+	bb := b.AsSlice()
+	r := 0
+	for i := range ab {
+		r += bits.OnesCount8(ab[i] ^ bb[i])
+	}
+	return r, nil
+}
+
+// MidpointAddr return the address halfway between a and b, i.e. a + (b-a)/2 rounded towards a;
+// a and b must be in same address family, zone is ignored
+func MidpointAddr(a, b netip.Addr) (netip.Addr, error) {
+	if err := sameFamily(a, b); err != nil {
+		return netip.Addr{}, err
+	}
+	an := netipToBig(a)
+	delta := new(big.Int).Sub(netipToBig(b), an)
+	delta.Quo(delta, big.NewInt(2))
+	return bigToNetip(an.Add(an, delta), a.BitLen())
+}
+
+// IPv4ToUint32 convert IPv4 addr to uint32
+func IPv4ToUint32(addr netip.Addr) (uint32, error) {
+	if !addr.Is4() {
+		return 0, fmt.Errorf("%v is not an IPv4 address", addr)
+	}
+	a4 := addr.As4()
+	return binary.BigEndian.Uint32(a4[:]), nil
+}
+
+// Uint32ToIPv4 convert n to IPv4 address
+func Uint32ToIPv4(n uint32) netip.Addr {
+	var a4 [4]byte
+	binary.BigEndian.PutUint32(a4[:], n)
+	return netip.AddrFrom4(a4)
+}
+
+// IPv6ToUint128 convert IPv6 addr to a 128 bit number, hi is the high 64 bits, lo is the low 64 bits;
+// zone of addr is ignored
+func IPv6ToUint128(addr netip.Addr) (hi, lo uint64, err error) {
+	if !addr.Is6() {
+		return 0, 0, fmt.Errorf("%v is not an IPv6 address", addr)
+	}
+	a16 := addr.As16()
+	return binary.BigEndian.Uint64(a16[:8]), binary.BigEndian.Uint64(a16[8:]), nil
+}
+
+// Uint128ToIPv6 convert a 128 bit number to IPv6 address, hi is the high 64 bits, lo is the low 64 bits
+func Uint128ToIPv6(hi, lo uint64) netip.Addr {
+	var a16 [16]byte
+	binary.BigEndian.PutUint64(a16[:8], hi)
+	binary.BigEndian.PutUint64(a16[8:], lo)
+	return netip.AddrFrom16(a16)
+}
+
+// AddrBytesReversed return bytes of addr in reversed (little-endian) order,
+// 4 bytes for IPv4, 16 bytes for IPv6; zone of addr is ignored, return nil if addr is invalid
+func AddrBytesReversed(addr netip.Addr) []byte {
+	b := addr.AsSlice()
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
+
+// AddrFromReversedBytes is the reverse of AddrBytesReversed,
+// b must be 4 bytes long for IPv4, 16 bytes long for IPv6
+func AddrFromReversedBytes(b []byte) (netip.Addr, error) {
+	if len(b) != 4 && len(b) != 16 {
+		return netip.Addr{}, fmt.Errorf("invalid address length %d", len(b))
+	}
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	addr, _ := netip.AddrFromSlice(r)
+	return addr, nil
+}
+
+// PutAddr write addr into buf starting from offset in network byte order,
+// return number of bytes written: 4 for IPv4, 16 for IPv6;
+// return error if addr is invalid or buf is too short
+func PutAddr(buf []byte, offset int, addr netip.Addr) (int, error) {
+	if !addr.IsValid() {
+		return 0, fmt.Errorf("invalid address %v", addr)
+	}
+	alen := addr.BitLen() / 8
+	if offset < 0 || len(buf)-offset < alen {
+		return 0, fmt.Errorf("buffer of %d bytes is too short to put %d bytes at offset %d", len(buf), alen, offset)
+	}
+	return copy(buf[offset:], addr.AsSlice()), nil
+}
+
+// GetAddr read an IPv4 address if ipv4 is true, IPv6 address otherwise,
+// from buf starting from offset in network byte order; return error if buf is too short
+func GetAddr(buf []byte, offset int, ipv4 bool) (netip.Addr, error) {
+	alen := 16
+	if ipv4 {
+		alen = 4
+	}
+	if offset < 0 || len(buf)-offset < alen {
+		return netip.Addr{}, fmt.Errorf("buffer of %d bytes is too short to get %d bytes at offset %d", len(buf), alen, offset)
+	}
+	addr, _ := netip.AddrFromSlice(buf[offset : offset+alen])
+	return addr, nil
+}
+
+// AddrHash return a 64 bit hash of addr with seed, using FNV-1a over seed and bytes of addr;
+// the result only depends on addr and seed, so it is same across processes and runs,
+// suitable for consistent sharding. zone of addr is ignored,
+// IPv4 address and its IPv4-mapped IPv6 form have different hash
+func AddrHash(addr netip.Addr, seed uint64) uint64 {
+	h := fnv.New64a()
+	var sb [8]byte
+	binary.BigEndian.PutUint64(sb[:], seed)
+	h.Write(sb[:])
+	h.Write(addr.AsSlice())
+	return h.Sum64()
+}
+
+// Addr is an IP address value that keeps its address family along with its numeric value,
+// so arithmetic on it never changes family, e.g. an IPv4-mapped IPv6 address stays IPv6;
+// zero value is an invalid Addr, Addr is immutable and safe for concurrent use
+type Addr struct {
+	n *big.Int
+	//32 for IPv4, 128 for IPv6, 0 for invalid
+	bitlen int
+}
+
+// AddrFromNetip return an Addr of addr, zone of addr is dropped;
+// an IPv4-mapped IPv6 addr is IPv6
+func AddrFromNetip(addr netip.Addr) (Addr, error) {
+	if !addr.IsValid() {
+		return Addr{}, fmt.Errorf("invalid address %v", addr)
+	}
+	return Addr{n: netipToBig(addr), bitlen: addr.BitLen()}, nil
+}
+
+// AddrFromIP return an Addr of ip, an IPv4-mapped IPv6 ip is IPv4 since net.IP doesn't
+// distinguish it from an IPv4 address, see ToNetip
+func AddrFromIP(ip net.IP) (Addr, error) {
+	addr, ok := ToNetip(ip)
+	if !ok {
+		return Addr{}, fmt.Errorf("invalid address %v", ip)
+	}
+	return AddrFromNetip(addr)
+}
+
+// ParseAddr parse address string s into an Addr, see AddrFromNetip
+func ParseAddr(s string) (Addr, error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return Addr{}, err
+	}
+	return AddrFromNetip(addr)
+}
+
+// IsValid return true if a is not the zero Addr
+func (a Addr) IsValid() bool {
+	return a.bitlen != 0
+}
+
+// Family return 4 if a is an IPv4 address, 6 if a is an IPv6 address, 0 if a is invalid
+func (a Addr) Family() int {
+	switch a.bitlen {
+	case 32:
+		return 4
+	case 128:
+		return 6
+	}
+	return 0
+}
+
+// Big return numeric value of a as a new *big.Int, nil if a is invalid
+func (a Addr) Big() *big.Int {
+	if !a.IsValid() {
+		return nil
+	}
+	return new(big.Int).Set(a.n)
+}
+
+// Inc return a increased by step (could be negative) in same address family,
+// return error if the result is out of the range of the family
+func (a Addr) Inc(step *big.Int) (Addr, error) {
+	if !a.IsValid() {
+		return Addr{}, fmt.Errorf("invalid address")
+	}
+	rn := new(big.Int).Add(a.n, step)
+	if rn.Sign() < 0 {
+		return Addr{}, fmt.Errorf("%v and step %d result in negative result", a, step)
+	}
+	if rn.BitLen() > a.bitlen {
+		return Addr{}, fmt.Errorf("%v and step %d result exceeds max IPv%d address", a, step, a.Family())
+	}
+	return Addr{n: rn, bitlen: a.bitlen}, nil
+}
+
+// ToNetip convert a to netip.Addr, return zero netip.Addr if a is invalid
+func (a Addr) ToNetip() netip.Addr {
+	if !a.IsValid() {
+		return netip.Addr{}
+	}
+	//a is always in range of its family
+	r, _ := bigToNetip(a.n, a.bitlen)
+	return r
+}
+
+// ToIP convert a to net.IP, an IPv4 a is converted to 4-byte form, return nil if a is invalid
+func (a Addr) ToIP() net.IP {
+	return ToNetIP(a.ToNetip())
+}
+
+// String return string form of a, "invalid Addr" if a is invalid
+func (a Addr) String() string {
+	if !a.IsValid() {
+		return "invalid Addr"
+	}
+	return a.ToNetip().String()
+}
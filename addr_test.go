@@ -0,0 +1,831 @@
+// myaddr_test
+package myaddr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestSortAddrs(t *testing.T) {
+	addrs := []netip.Addr{
+		netip.MustParseAddr("fe80::1%eth1"),
+		netip.MustParseAddr("10.0.0.2"),
+		netip.MustParseAddr("2001:dead::1"),
+		netip.MustParseAddr("fe80::1"),
+		netip.MustParseAddr("::ffff:10.0.0.1"),
+		netip.MustParseAddr("10.0.0.1"),
+		{},
+		netip.MustParseAddr("fe80::1%eth0"),
+	}
+	expected := "[invalid IP 10.0.0.1 10.0.0.2 ::ffff:10.0.0.1 2001:dead::1 fe80::1 fe80::1%eth0 fe80::1%eth1]"
+	SortAddrs(addrs)
+	if fmt.Sprint(addrs) != expected {
+		t.Fatalf("result %v is different from expected %v", addrs, expected)
+	}
+	if CompareAddrs(netip.MustParseAddr("255.255.255.255"), netip.MustParseAddr("::")) != -1 {
+		t.Fatal("IPv4 address should be smaller than IPv6 address")
+	}
+	if CompareAddrs(netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("10.0.0.1")) != 0 {
+		t.Fatal("same addresses should be equal")
+	}
+}
+
+type testAddrRangeJSONCase struct {
+	jsonStr    string
+	start, end string
+	shouldFail bool
+}
+
+func TestAddrRangeJSON(t *testing.T) {
+	testData := []testAddrRangeJSONCase{
+		{
+			jsonStr: `"192.168.1.1-192.168.1.100"`,
+			start:   "192.168.1.1",
+			end:     "192.168.1.100",
+		},
+		{
+			jsonStr: `"2001:dead::1-2001:dead::1"`,
+			start:   "2001:dead::1",
+			end:     "2001:dead::1",
+		},
+		{
+			jsonStr:    `"192.168.1.100-192.168.1.1"`,
+			shouldFail: true,
+		},
+		{
+			jsonStr:    `"192.168.1.1-2001:dead::1"`,
+			shouldFail: true,
+		},
+		{
+			jsonStr:    `"192.168.1.1"`,
+			shouldFail: true,
+		},
+		{
+			jsonStr:    `"192.168.1.1-192.168.1.300"`,
+			shouldFail: true,
+		},
+		//zone contains "-"
+		{
+			jsonStr: `"fe80::1%eth-0-fe80::ff%eth-0"`,
+			start:   "fe80::1%eth-0",
+			end:     "fe80::ff%eth-0",
+		},
+		{
+			jsonStr: `"fe80::1-fe80::ff%eth-0"`,
+			start:   "fe80::1",
+			end:     "fe80::ff%eth-0",
+		},
+		//could be split as ::1%a and ::2%b-::3, or ::1%a-::2%b and ::3
+		{
+			jsonStr:    `"::1%a-::2%b-::3"`,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testAddrRangeJSONCase) error {
+		var r AddrRangeT
+		if err := json.Unmarshal([]byte(c.jsonStr), &r); err != nil {
+			return err
+		}
+		if r.Start.String() != c.start || r.End.String() != c.end {
+			return fmt.Errorf("result %v-%v is different from expected %v-%v", r.Start, r.End, c.start, c.end)
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if string(b) != c.jsonStr {
+			return fmt.Errorf("marshaled result %v is different from original %v", string(b), c.jsonStr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestIncAddrKeepZone(t *testing.T) {
+	testData := []testIncCase{
+		{
+			addrStr:      "fe80::1%eth0",
+			step:         1,
+			expectedAddr: "fe80::2%eth0",
+		},
+		{
+			addrStr:      "fe80::1%eth0",
+			step:         -1,
+			expectedAddr: "fe80::%eth0",
+		},
+		{
+			addrStr:      "2001:dead::ffff",
+			step:         1,
+			expectedAddr: "2001:dead::1:0",
+		},
+		{
+			addrStr:      "1.1.1.255",
+			step:         1,
+			expectedAddr: "1.1.2.0",
+		},
+		{
+			addrStr:      "::ffff:1.1.1.255",
+			step:         1,
+			expectedAddr: "::ffff:1.1.2.0",
+		},
+		{
+			addrStr:    "255.255.255.255",
+			step:       1,
+			shouldFail: true,
+		},
+		{
+			addrStr:    "::%eth0",
+			step:       -1,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testIncCase) error {
+		raddr, err := IncAddrKeepZone(netip.MustParseAddr(c.addrStr), big.NewInt(c.step))
+		if err != nil {
+			return err
+		}
+		if raddr.String() != c.expectedAddr {
+			return fmt.Errorf("result addr %v is different from expected %v", raddr, c.expectedAddr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testApplyMaskCase struct {
+	addr, mask   string
+	expectedAddr string
+	shouldFail   bool
+}
+
+func TestApplyMask(t *testing.T) {
+	testData := []testApplyMaskCase{
+		{
+			addr:         "192.168.1.100",
+			mask:         "255.255.255.0",
+			expectedAddr: "192.168.1.0",
+		},
+		{
+			addr:         "192.168.1.100",
+			mask:         "255.0.255.0",
+			expectedAddr: "192.0.1.0",
+		},
+		{
+			addr:         "192.168.1.100",
+			mask:         "0.0.0.15",
+			expectedAddr: "0.0.0.4",
+		},
+		{
+			addr:         "fe80::1234:5678%eth0",
+			mask:         "ffff::ff00:ff",
+			expectedAddr: "fe80::1200:78%eth0",
+		},
+		{
+			addr:       "192.168.1.100",
+			mask:       "ffff::",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testApplyMaskCase) error {
+		r, err := ApplyMask(netip.MustParseAddr(c.addr), netip.MustParseAddr(c.mask))
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expectedAddr {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedAddr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testExpandIPv6Case struct {
+	compressed string
+	expanded   string
+	shouldFail bool
+}
+
+func TestExpandIPv6(t *testing.T) {
+	testData := []testExpandIPv6Case{
+		{
+			compressed: "2001:db8::1",
+			expanded:   "2001:0db8:0000:0000:0000:0000:0000:0001",
+		},
+		{
+			compressed: "::",
+			expanded:   "0000:0000:0000:0000:0000:0000:0000:0000",
+		},
+		{
+			compressed: "fe80::1%eth0",
+			expanded:   "fe80:0000:0000:0000:0000:0000:0000:0001%eth0",
+		},
+		{
+			compressed: "1.2.3.4",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testExpandIPv6Case) error {
+		addr, err := CompressIPv6(c.compressed)
+		if err != nil {
+			return err
+		}
+		s, err := ExpandIPv6(addr)
+		if err != nil {
+			return err
+		}
+		if s != c.expanded {
+			return fmt.Errorf("result %v is different from expected %v", s, c.expanded)
+		}
+		addr, err = CompressIPv6(s)
+		if err != nil {
+			return err
+		}
+		if addr.String() != c.compressed {
+			return fmt.Errorf("result %v is different from expected %v", addr, c.compressed)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if _, err := ExpandIPv6(netip.MustParseAddr("1.2.3.4")); err == nil {
+		t.Fatal("expanding IPv4 address should fail")
+	}
+}
+
+func TestMulticastAddrs(t *testing.T) {
+	testData := []struct {
+		addr     netip.Addr
+		expected string
+	}{
+		{AllNodesMulticast(), "ff02::1"},
+		{AllRoutersMulticast(), "ff02::2"},
+		{AllHostsMulticastIPv4(), "224.0.0.1"},
+		{AllRoutersMulticastIPv4(), "224.0.0.2"},
+	}
+	for _, c := range testData {
+		if c.addr.String() != c.expected {
+			t.Fatalf("result %v is different from expected %v", c.addr, c.expected)
+		}
+		if !c.addr.IsMulticast() {
+			t.Fatalf("%v is not multicast", c.addr)
+		}
+	}
+}
+
+func TestZeroMaxAddr(t *testing.T) {
+	testData := []struct {
+		addr     netip.Addr
+		expected string
+	}{
+		{ZeroAddr(true), "0.0.0.0"},
+		{ZeroAddr(false), "::"},
+		{MaxAddr(true), "255.255.255.255"},
+		{MaxAddr(false), "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+	}
+	for _, c := range testData {
+		if c.addr.String() != c.expected {
+			t.Fatalf("result %v is different from expected %v", c.addr, c.expected)
+		}
+	}
+	if _, err := IncAddrKeepZone(MaxAddr(true), big.NewInt(1)); err == nil {
+		t.Fatal("increasing max IPv4 address should fail")
+	}
+	if _, err := IncAddrKeepZone(ZeroAddr(false), big.NewInt(-1)); err == nil {
+		t.Fatal("decreasing zero IPv6 address should fail")
+	}
+}
+
+type testMatchingPrefixBitsCase struct {
+	a, b         string
+	expectedBits int
+	shouldFail   bool
+}
+
+func TestMatchingPrefixBits(t *testing.T) {
+	testData := []testMatchingPrefixBitsCase{
+		{
+			a:            "192.168.1.1",
+			b:            "192.168.1.1",
+			expectedBits: 32,
+		},
+		{
+			a:            "0.0.0.0",
+			b:            "128.0.0.0",
+			expectedBits: 0,
+		},
+		{
+			a:            "192.168.1.1",
+			b:            "192.168.1.129",
+			expectedBits: 24,
+		},
+		{
+			a:            "10.0.0.0",
+			b:            "10.1.0.0",
+			expectedBits: 15,
+		},
+		{
+			a:            "2001:dead::1",
+			b:            "2001:dead::1",
+			expectedBits: 128,
+		},
+		{
+			a:            "2001:dead::1",
+			b:            "2001:dead::",
+			expectedBits: 127,
+		},
+		{
+			a:            "::",
+			b:            "ffff::",
+			expectedBits: 0,
+		},
+		{
+			a:          "10.0.0.0",
+			b:          "::",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testMatchingPrefixBitsCase) error {
+		r, err := MatchingPrefixBits(netip.MustParseAddr(c.a), netip.MustParseAddr(c.b))
+		if err != nil {
+			return err
+		}
+		if r != c.expectedBits {
+			return fmt.Errorf("result %d is different from expected %d", r, c.expectedBits)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestIPv4Uint32(t *testing.T) {
+	testData := []struct {
+		addr string
+		n    uint32
+	}{
+		{"0.0.0.0", 0},
+		{"0.0.1.0", 256},
+		{"192.168.1.1", 0xc0a80101},
+		{"255.255.255.255", 0xffffffff},
+	}
+	for _, c := range testData {
+		n, err := IPv4ToUint32(netip.MustParseAddr(c.addr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != c.n {
+			t.Fatalf("result %d is different from expected %d", n, c.n)
+		}
+		if addr := Uint32ToIPv4(n); addr.String() != c.addr {
+			t.Fatalf("converted back addr %v is different from original %v", addr, c.addr)
+		}
+	}
+	if _, err := IPv4ToUint32(netip.MustParseAddr("::1")); err == nil {
+		t.Fatal("converting IPv6 address should fail")
+	}
+}
+
+func TestIPv6Uint128(t *testing.T) {
+	testData := []struct {
+		addr   string
+		hi, lo uint64
+	}{
+		{"::", 0, 0},
+		{"::1", 0, 1},
+		{"2001:db8::1", 0x20010db800000000, 1},
+		{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", 0xffffffffffffffff, 0xffffffffffffffff},
+	}
+	for _, c := range testData {
+		hi, lo, err := IPv6ToUint128(netip.MustParseAddr(c.addr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hi != c.hi || lo != c.lo {
+			t.Fatalf("result %x,%x is different from expected %x,%x", hi, lo, c.hi, c.lo)
+		}
+		if addr := Uint128ToIPv6(hi, lo); addr.String() != c.addr {
+			t.Fatalf("converted back addr %v is different from original %v", addr, c.addr)
+		}
+	}
+	if _, _, err := IPv6ToUint128(netip.MustParseAddr("1.1.1.1")); err == nil {
+		t.Fatal("converting IPv4 address should fail")
+	}
+}
+
+type testAddrIncCase struct {
+	addr         string
+	step         int64
+	expectedAddr string
+	expectedFam  int
+	shouldFail   bool
+}
+
+func TestAddrInc(t *testing.T) {
+	testData := []testAddrIncCase{
+		{
+			addr:         "192.168.1.255",
+			step:         1,
+			expectedAddr: "192.168.2.0",
+			expectedFam:  4,
+		},
+		{
+			addr:         "2001:dead::1%eth0",
+			step:         -1,
+			expectedAddr: "2001:dead::",
+			expectedFam:  6,
+		},
+		//IPv4-mapped IPv6 address stays IPv6
+		{
+			addr:         "::ffff:255.255.255.255",
+			step:         1,
+			expectedAddr: "::1:0:0:0",
+			expectedFam:  6,
+		},
+		{
+			addr:       "255.255.255.255",
+			step:       1,
+			shouldFail: true,
+		},
+		{
+			addr:       "0.0.0.0",
+			step:       -1,
+			shouldFail: true,
+		},
+		{
+			addr:       "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+			step:       1,
+			shouldFail: true,
+		},
+		{
+			addr:       "1.1.1",
+			step:       1,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testAddrIncCase) error {
+		a, err := ParseAddr(c.addr)
+		if err != nil {
+			return err
+		}
+		r, err := a.Inc(big.NewInt(c.step))
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expectedAddr {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedAddr)
+		}
+		if r.Family() != c.expectedFam {
+			return fmt.Errorf("result family %d is different from expected %d", r.Family(), c.expectedFam)
+		}
+		if r.ToNetip() != netip.MustParseAddr(c.expectedAddr) {
+			return fmt.Errorf("ToNetip result %v is different from expected %v", r.ToNetip(), c.expectedAddr)
+		}
+		if !r.ToIP().Equal(net.ParseIP(c.expectedAddr)) {
+			return fmt.Errorf("ToIP result %v is different from expected %v", r.ToIP(), c.expectedAddr)
+		}
+		//a is not changed
+		if a.ToNetip() != netip.MustParseAddr(c.addr).WithZone("") {
+			return fmt.Errorf("original addr is changed to %v", a)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestAddrConstructors(t *testing.T) {
+	a, err := AddrFromIP(net.ParseIP("10.0.0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Family() != 4 || a.Big().Int64() != 0x0a000001 || len(a.ToIP()) != 4 {
+		t.Fatalf("unexpected result %v, family %d", a, a.Family())
+	}
+	a, err = AddrFromNetip(netip.MustParseAddr("::ffff:10.0.0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Family() != 6 {
+		t.Fatalf("family of %v is %d, expect 6", a, a.Family())
+	}
+	if _, err = AddrFromIP(net.IP{1, 2}); err == nil {
+		t.Fatal("invalid net.IP should fail")
+	}
+	if _, err = AddrFromNetip(netip.Addr{}); err == nil {
+		t.Fatal("invalid netip.Addr should fail")
+	}
+	var zero Addr
+	if zero.IsValid() || zero.Family() != 0 || zero.Big() != nil || zero.ToIP() != nil || zero.ToNetip().IsValid() {
+		t.Fatal("zero Addr should be invalid")
+	}
+	if _, err = zero.Inc(big.NewInt(1)); err == nil {
+		t.Fatal("increasing zero Addr should fail")
+	}
+}
+
+func TestAddrBytesReversed(t *testing.T) {
+	testData := []struct {
+		addr     string
+		expected string
+	}{
+		{"192.168.1.2", "[2 1 168 192]"},
+		{"2001:db8::1", "[1 0 0 0 0 0 0 0 0 0 0 0 184 13 1 32]"},
+		{"::ffff:1.2.3.4", "[4 3 2 1 255 255 0 0 0 0 0 0 0 0 0 0]"},
+	}
+	for i, c := range testData {
+		addr := netip.MustParseAddr(c.addr)
+		b := AddrBytesReversed(addr)
+		if fmt.Sprint(b) != c.expected {
+			t.Fatalf("case %d: result %v is different from expected %v", i, b, c.expected)
+		}
+		r, err := AddrFromReversedBytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r != addr {
+			t.Fatalf("case %d: round trip result %v is different from %v", i, r, addr)
+		}
+	}
+	if b := AddrBytesReversed(netip.Addr{}); b != nil {
+		t.Fatalf("result of invalid address %v is not nil", b)
+	}
+	if _, err := AddrFromReversedBytes([]byte{1, 2, 3}); err == nil {
+		t.Fatal("3 bytes should fail")
+	}
+}
+
+type testMidpointAddrCase struct {
+	a, b       string
+	expected   string
+	shouldFail bool
+}
+
+func TestMidpointAddr(t *testing.T) {
+	testData := []testMidpointAddrCase{
+		{a: "10.0.0.0", b: "10.0.0.10", expected: "10.0.0.5"},
+		{a: "10.0.0.10", b: "10.0.0.0", expected: "10.0.0.5"},
+		{a: "10.0.0.0", b: "10.0.0.1", expected: "10.0.0.0"},
+		{a: "10.0.0.1", b: "10.0.0.0", expected: "10.0.0.1"},
+		{a: "10.0.0.7", b: "10.0.0.7", expected: "10.0.0.7"},
+		{a: "0.0.0.0", b: "255.255.255.255", expected: "127.255.255.255"},
+		{a: "::", b: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", expected: "7fff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+		{a: "10.0.0.0", b: "::a00:a", shouldFail: true},
+	}
+	runTest := func(c testMidpointAddrCase) error {
+		r, err := MidpointAddr(netip.MustParseAddr(c.a), netip.MustParseAddr(c.b))
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testPutGetAddrCase struct {
+	bufLen     int
+	offset     int
+	addr       string
+	expectedN  int
+	shouldFail bool
+}
+
+func TestPutGetAddr(t *testing.T) {
+	testData := []testPutGetAddrCase{
+		{bufLen: 20, offset: 12, addr: "192.168.1.1", expectedN: 4},
+		{bufLen: 16, offset: 12, addr: "192.168.1.1", expectedN: 4},
+		{bufLen: 40, offset: 8, addr: "2001:db8::1", expectedN: 16},
+		{bufLen: 24, offset: 8, addr: "::ffff:1.2.3.4", expectedN: 16},
+		{bufLen: 15, offset: 12, addr: "192.168.1.1", shouldFail: true},
+		{bufLen: 20, offset: 8, addr: "2001:db8::1", shouldFail: true},
+		{bufLen: 20, offset: -1, addr: "192.168.1.1", shouldFail: true},
+		{bufLen: 20, offset: 30, addr: "192.168.1.1", shouldFail: true},
+	}
+	runTest := func(c testPutGetAddrCase) error {
+		buf := make([]byte, c.bufLen)
+		addr := netip.MustParseAddr(c.addr)
+		n, err := PutAddr(buf, c.offset, addr)
+		if err != nil {
+			return err
+		}
+		if n != c.expectedN {
+			return fmt.Errorf("written %d bytes, expect %d", n, c.expectedN)
+		}
+		r, err := GetAddr(buf, c.offset, addr.Is4())
+		if err != nil {
+			return err
+		}
+		if r != addr {
+			return fmt.Errorf("read %v is different from written %v", r, addr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if _, err := PutAddr(make([]byte, 16), 0, netip.Addr{}); err == nil {
+		t.Fatal("putting invalid address should fail")
+	}
+	if _, err := GetAddr(make([]byte, 15), 0, false); err == nil {
+		t.Fatal("getting IPv6 address from 15 bytes buffer should fail")
+	}
+}
+
+type testAddrInRangeCase struct {
+	addr, low, high string
+	expected        bool
+	shouldFail      bool
+}
+
+func TestAddrInRange(t *testing.T) {
+	testData := []testAddrInRangeCase{
+		{addr: "10.0.0.5", low: "10.0.0.1", high: "10.0.0.10", expected: true},
+		{addr: "10.0.0.1", low: "10.0.0.1", high: "10.0.0.10", expected: true},
+		{addr: "10.0.0.10", low: "10.0.0.1", high: "10.0.0.10", expected: true},
+		{addr: "10.0.0.0", low: "10.0.0.1", high: "10.0.0.10", expected: false},
+		{addr: "10.0.0.11", low: "10.0.0.1", high: "10.0.0.10", expected: false},
+		{addr: "10.0.0.1", low: "10.0.0.1", high: "10.0.0.1", expected: true},
+		{addr: "10.0.0.2", low: "10.0.0.1", high: "10.0.0.1", expected: false},
+		{addr: "fe80::1%eth0", low: "fe80::1", high: "fe80::ff", expected: true},
+		{addr: "10.0.0.5", low: "10.0.0.10", high: "10.0.0.1", shouldFail: true},
+		{addr: "::a00:5", low: "10.0.0.1", high: "10.0.0.10", shouldFail: true},
+		{addr: "10.0.0.5", low: "10.0.0.1", high: "::a00:a", shouldFail: true},
+	}
+	runTest := func(c testAddrInRangeCase) error {
+		r, err := AddrInRange(netip.MustParseAddr(c.addr), netip.MustParseAddr(c.low), netip.MustParseAddr(c.high))
+		if err != nil {
+			return err
+		}
+		if r != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testHammingDistanceCase struct {
+	a, b       string
+	expected   int
+	shouldFail bool
+}
+
+func TestHammingDistance(t *testing.T) {
+	testData := []testHammingDistanceCase{
+		{a: "192.168.1.1", b: "192.168.1.1", expected: 0},
+		{a: "0.0.0.0", b: "255.255.255.255", expected: 32},
+		{a: "10.0.0.1", b: "10.0.0.2", expected: 2},
+		{a: "2001:dead::1", b: "2001:dead::1", expected: 0},
+		{a: "::", b: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", expected: 128},
+		{a: "2001:dead::f", b: "2001:deac::", expected: 5},
+		{a: "10.0.0.0", b: "::", shouldFail: true},
+	}
+	runTest := func(c testHammingDistanceCase) error {
+		r, err := HammingDistance(netip.MustParseAddr(c.a), netip.MustParseAddr(c.b))
+		if err != nil {
+			return err
+		}
+		if r != c.expected {
+			return fmt.Errorf("result %d is different from expected %d", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestAddrHash(t *testing.T) {
+	addr := netip.MustParseAddr("10.0.0.1")
+	//FNV-1a of seed 1 and 10.0.0.1, stays same across runs
+	if h := AddrHash(addr, 1); h != 0x798d6e1a503674b {
+		t.Fatalf("hash %#x is different from expected 0x798d6e1a503674b", h)
+	}
+	if AddrHash(addr, 1) == AddrHash(addr, 2) {
+		t.Fatal("different seeds result in same hash")
+	}
+	if AddrHash(netip.MustParseAddr("fe80::1%eth0"), 0) != AddrHash(netip.MustParseAddr("fe80::1"), 0) {
+		t.Fatal("zone should be ignored")
+	}
+	const buckets = 16
+	var count [buckets]int
+	prefix := netip.MustParsePrefix("10.0.0.0/16")
+	for a := prefix.Addr(); prefix.Contains(a); a = a.Next() {
+		count[AddrHash(a, 0)%buckets]++
+	}
+	//each bucket should get roughly 65536/16 = 4096 addresses
+	for i, n := range count {
+		if n < 3600 || n > 4600 {
+			t.Fatalf("bucket %d has %d addresses, distribution is uneven: %v", i, n, count)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 Hu Jun. All rights reserved.
+// This project is licensed under the terms of the MIT license.
+// license that can be found in the LICENSE file.
+
+package myaddr
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strings"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// ReverseDNSName return the PTR name of addr, e.g.
+// 1.2.3.4 -> 4.3.2.1.in-addr.arpa.
+// 2001:db8::1 -> 1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.
+func ReverseDNSName(addr netip.Addr) (string, error) {
+	if !addr.IsValid() {
+		return "", fmt.Errorf("invalid address %v", addr)
+	}
+	b := addr.AsSlice()
+	var sb strings.Builder
+	if addr.Is4() {
+		for i := len(b) - 1; i >= 0; i-- {
+			fmt.Fprintf(&sb, "%d.", b[i])
+		}
+		sb.WriteString("in-addr.arpa.")
+		return sb.String(), nil
+	}
+	for i := len(b) - 1; i >= 0; i-- {
+		sb.WriteByte(hexDigits[b[i]&0xf])
+		sb.WriteByte('.')
+		sb.WriteByte(hexDigits[b[i]>>4])
+		sb.WriteByte('.')
+	}
+	sb.WriteString("ip6.arpa.")
+	return sb.String(), nil
+}
+
+// reverseZoneName return the reverse zone name of the first labels octets (IPv4)
+// or nibbles (IPv6) of addr
+func reverseZoneName(addr netip.Addr, labels int) (string, error) {
+	name, err := ReverseDNSName(addr)
+	if err != nil {
+		return "", err
+	}
+	all := strings.Split(name, ".")
+	//all ends with "in-addr"/"ip6", "arpa", ""
+	total := len(all) - 3
+	return strings.Join(all[total-labels:], "."), nil
+}
+
+// ReverseZones return the reverse DNS zone names covering prefix:
+//   - prefix on octet (IPv4) or nibble (IPv6) boundary is covered by a single zone,
+//     e.g. 192.168.1.0/24 -> 1.168.192.in-addr.arpa.
+//   - IPv4 prefix longer than /24 is covered by a single RFC 2317 classless zone,
+//     e.g. 192.168.1.128/25 -> 128/25.1.168.192.in-addr.arpa.
+//   - other prefix is covered by multiple zones on next octet or nibble boundary,
+//     e.g. 10.0.0.0/15 -> 0.10.in-addr.arpa. and 1.10.in-addr.arpa.
+func ReverseZones(prefix netip.Prefix) ([]string, error) {
+	if !prefix.IsValid() {
+		return nil, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	prefix = prefix.Masked()
+	labelBits := 4
+	if prefix.Addr().Is4() {
+		labelBits = 8
+		if prefix.Bits() > 24 && prefix.Bits() < 32 {
+			zone, err := reverseZoneName(prefix.Addr(), 3)
+			if err != nil {
+				return nil, err
+			}
+			return []string{fmt.Sprintf("%d/%d.%v", prefix.Addr().As4()[3], prefix.Bits(), zone)}, nil
+		}
+	}
+	labels := (prefix.Bits() + labelBits - 1) / labelBits
+	zoneBits := labels * labelBits
+	count := 1 << (zoneBits - prefix.Bits())
+	step := new(big.Int).Lsh(big.NewInt(1), uint(prefix.Addr().BitLen()-zoneBits))
+	cur := netipToBig(prefix.Addr())
+	r := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		addr, err := bigToNetip(cur, prefix.Addr().BitLen())
+		if err != nil {
+			return nil, err
+		}
+		zone, err := reverseZoneName(addr, labels)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, zone)
+		cur.Add(cur, step)
+	}
+	return r, nil
+}
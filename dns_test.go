@@ -0,0 +1,150 @@
+// myaddr_test
+package myaddr
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+type testReverseDNSNameCase struct {
+	addr         netip.Addr
+	expectedName string
+	shouldFail   bool
+}
+
+func TestReverseDNSName(t *testing.T) {
+	testData := []testReverseDNSNameCase{
+		{
+			addr:         netip.MustParseAddr("1.2.3.4"),
+			expectedName: "4.3.2.1.in-addr.arpa.",
+		},
+		{
+			addr:         netip.MustParseAddr("192.168.0.10"),
+			expectedName: "10.0.168.192.in-addr.arpa.",
+		},
+		{
+			addr:         netip.MustParseAddr("2001:db8::1"),
+			expectedName: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+		},
+		{
+			addr:         netip.MustParseAddr("fe80::abcd:ef12%eth0"),
+			expectedName: "2.1.f.e.d.c.b.a.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.e.f.ip6.arpa.",
+		},
+		{
+			addr:       netip.Addr{},
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testReverseDNSNameCase) error {
+		name, err := ReverseDNSName(c.addr)
+		if err != nil {
+			return err
+		}
+		if name != c.expectedName {
+			return fmt.Errorf("result %v is different from expected %v", name, c.expectedName)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testReverseZonesCase struct {
+	prefix        string
+	expectedZones []string
+	shouldFail    bool
+}
+
+func TestReverseZones(t *testing.T) {
+	testData := []testReverseZonesCase{
+		{
+			prefix:        "192.168.1.0/24",
+			expectedZones: []string{"1.168.192.in-addr.arpa."},
+		},
+		{
+			prefix:        "10.0.0.0/8",
+			expectedZones: []string{"10.in-addr.arpa."},
+		},
+		{
+			prefix:        "0.0.0.0/0",
+			expectedZones: []string{"in-addr.arpa."},
+		},
+		{
+			prefix:        "192.168.1.1/32",
+			expectedZones: []string{"1.1.168.192.in-addr.arpa."},
+		},
+		{
+			prefix:        "192.168.1.128/25",
+			expectedZones: []string{"128/25.1.168.192.in-addr.arpa."},
+		},
+		{
+			prefix:        "192.168.1.64/26",
+			expectedZones: []string{"64/26.1.168.192.in-addr.arpa."},
+		},
+		{
+			prefix:        "10.0.0.0/15",
+			expectedZones: []string{"0.10.in-addr.arpa.", "1.10.in-addr.arpa."},
+		},
+		{
+			prefix:        "192.168.4.0/22",
+			expectedZones: []string{"4.168.192.in-addr.arpa.", "5.168.192.in-addr.arpa.", "6.168.192.in-addr.arpa.", "7.168.192.in-addr.arpa."},
+		},
+		{
+			prefix:        "2001:db8::/32",
+			expectedZones: []string{"8.b.d.0.1.0.0.2.ip6.arpa."},
+		},
+		{
+			prefix:        "2001:db8::/31",
+			expectedZones: []string{"8.b.d.0.1.0.0.2.ip6.arpa.", "9.b.d.0.1.0.0.2.ip6.arpa."},
+		},
+		{
+			prefix:        "2001:db8:1200::/39",
+			expectedZones: []string{"2.1.8.b.d.0.1.0.0.2.ip6.arpa.", "3.1.8.b.d.0.1.0.0.2.ip6.arpa."},
+		},
+		{
+			prefix:        "::/0",
+			expectedZones: []string{"ip6.arpa."},
+		},
+		{
+			prefix:     "",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testReverseZonesCase) error {
+		var prefix netip.Prefix
+		if c.prefix != "" {
+			prefix = netip.MustParsePrefix(c.prefix)
+		}
+		zones, err := ReverseZones(prefix)
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(zones) != fmt.Sprint(c.expectedZones) {
+			return fmt.Errorf("result %v is different from expected %v", zones, c.expectedZones)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/hujun-open/myaddr
 
-go 1.14
+go 1.23
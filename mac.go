@@ -0,0 +1,208 @@
+// Copyright 2020 Hu Jun. All rights reserved.
+// This project is licensed under the terms of the MIT license.
+// license that can be found in the LICENSE file.
+
+package myaddr
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// MACRange return count MAC addresses, starting from start, each is step (could be negative) apart
+// from previous one; return error if any address is out of range 00:00:00:00:00:00 - FF:FF:FF:FF:FF:FF
+func MACRange(start net.HardwareAddr, count int, step *big.Int) ([]net.HardwareAddr, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("count %d is negative", count)
+	}
+	r := make([]net.HardwareAddr, 0, count)
+	if count == 0 {
+		return r, nil
+	}
+	cur, err := IncMACAddr(start, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	r = append(r, cur)
+	for i := 1; i < count; i++ {
+		cur, err = IncMACAddr(cur, step)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, cur)
+	}
+	return r, nil
+}
+
+// MACInOUI return a MAC address = oui + index, oui must be 3 bytes long,
+// index must be in range [0, 2^24)
+func MACInOUI(oui net.HardwareAddr, index *big.Int) (net.HardwareAddr, error) {
+	if len(oui) != 3 {
+		return nil, fmt.Errorf("%v is not a 3 byte OUI", oui)
+	}
+	if index == nil {
+		return nil, fmt.Errorf("index is not set")
+	}
+	if index.Sign() < 0 || index.BitLen() > 24 {
+		return nil, fmt.Errorf("index %v is out of range [0, 2^24)", index)
+	}
+	r := make(net.HardwareAddr, 6)
+	copy(r, oui)
+	index.FillBytes(r[3:])
+	return r, nil
+}
+
+// isUniversalUnicastMAC return true if mac number n is not all-zero,
+// and both multicast (I/G) bit and local (U/L) bit of first byte are clear
+func isUniversalUnicastMAC(n *big.Int) bool {
+	return n.Sign() != 0 && new(big.Int).Rsh(n, 40).Uint64()&0x3 == 0
+}
+
+// IncUnicastMAC increase 6-byte mac by step (could be negative) like IncMACAddr,
+// but skip any result that is not a universal unicast address, i.e. all-zero address or address with
+// multicast or local bit set, by continuing adding step until a valid one is found;
+// e.g. 00:ff:ff:ff:ff:ff + 1 is 04:00:00:00:00:00 since 01:xx, 02:xx and 03:xx are skipped.
+// return error if no valid address is found before going out of range 00:00:00:00:00:00 - FF:FF:FF:FF:FF:FF,
+// or step is 0 and mac is not a universal unicast address
+func IncUnicastMAC(mac net.HardwareAddr, step *big.Int) (net.HardwareAddr, error) {
+	if len(mac) != 6 {
+		return nil, fmt.Errorf("%v is not a 6-byte MAC address", mac)
+	}
+	absStep := new(big.Int).Abs(step)
+	cur := new(big.Int).Add(HWAddrtoBig(mac), step)
+	for {
+		if cur.Sign() < 0 || cur.BitLen() > 48 {
+			return nil, fmt.Errorf("%v and step %d result in no unicast address in range 00:00:00:00:00:00 - FF:FF:FF:FF:FF:FF", mac, step)
+		}
+		if isUniversalUnicastMAC(cur) {
+			return BigtoMACAddr(cur)
+		}
+		if step.Sign() == 0 {
+			return nil, fmt.Errorf("%v is not a universal unicast MAC address", mac)
+		}
+		//jump over the whole block of invalid first byte, in multiple of step
+		b := new(big.Int).Rsh(cur, 40).Int64()
+		var target, delta *big.Int
+		switch {
+		case b&0x3 == 0:
+			//all-zero address
+			cur.Add(cur, step)
+			continue
+		case step.Sign() > 0:
+			//first address of next valid first byte
+			target = new(big.Int).Lsh(big.NewInt(b|0x3+1), 40)
+			delta = new(big.Int).Sub(target, cur)
+		default:
+			//last address of previous valid first byte
+			target = new(big.Int).Lsh(big.NewInt(b&^0x3+1), 40)
+			target.Sub(target, big.NewInt(1))
+			delta = new(big.Int).Sub(cur, target)
+		}
+		//number of steps to reach target, rounded up
+		k := new(big.Int).Add(delta, absStep)
+		k.Sub(k, big.NewInt(1))
+		k.Quo(k, absStep)
+		cur.Add(cur, k.Mul(k, step))
+	}
+}
+
+// MACsByOUI return count MAC addresses generated like MACRange, grouped by their 3 byte OUI,
+// key of returned map is the OUI string like "00:11:22", addresses of each OUI are in generated order;
+// return error if any address is out of range 00:00:00:00:00:00 - FF:FF:FF:FF:FF:FF
+func MACsByOUI(start net.HardwareAddr, count int, step *big.Int) (map[string][]net.HardwareAddr, error) {
+	macs, err := MACRange(start, count, step)
+	if err != nil {
+		return nil, err
+	}
+	r := make(map[string][]net.HardwareAddr)
+	for _, mac := range macs {
+		oui := mac[:3].String()
+		r[oui] = append(r[oui], mac)
+	}
+	return r, nil
+}
+
+// FormatMAC return string form of mac, with sep between bytes, in upper case hex if upper is true;
+// if sep is '.', bytes are grouped by 2 as Cisco format like 0011.2233.4455;
+// if sep is 0, there is no separator
+func FormatMAC(mac net.HardwareAddr, sep byte, upper bool) string {
+	digits := hexDigits
+	if upper {
+		digits = strings.ToUpper(hexDigits)
+	}
+	group := 1
+	if sep == '.' {
+		group = 2
+	}
+	var sb strings.Builder
+	for i, b := range mac {
+		if i > 0 && i%group == 0 && sep != 0 {
+			sb.WriteByte(sep)
+		}
+		sb.WriteByte(digits[b>>4])
+		sb.WriteByte(digits[b&0xf])
+	}
+	return sb.String()
+}
+
+// FormatMACCisco return Cisco format of mac, like 0011.2233.4455
+func FormatMACCisco(mac net.HardwareAddr) string {
+	return FormatMAC(mac, '.', false)
+}
+
+// ParseMACFlexible parse a 6-byte MAC address string s in one of following formats:
+// 00:11:22:33:44:55, 00-11-22-33-44-55, 0011.2233.4455 or 001122334455
+func ParseMACFlexible(s string) (net.HardwareAddr, error) {
+	var mac net.HardwareAddr
+	if len(s) == 12 {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %v as a MAC address, %w", s, err)
+		}
+		mac = b
+	} else {
+		var err error
+		mac, err = net.ParseMAC(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(mac) != 6 {
+		return nil, fmt.Errorf("%v is not a 6-byte MAC address", s)
+	}
+	return mac, nil
+}
+
+// ValidateMAC return error if mac is not a 6-byte MAC address
+func ValidateMAC(mac net.HardwareAddr) error {
+	if len(mac) != 6 {
+		return fmt.Errorf("%v is not a 6-byte MAC address", mac)
+	}
+	return nil
+}
+
+// ValidateEUI64 return error if mac is not an 8-byte EUI-64 address
+func ValidateEUI64(mac net.HardwareAddr) error {
+	if len(mac) != 8 {
+		return fmt.Errorf("%v is not an 8-byte EUI-64 address", mac)
+	}
+	return nil
+}
+
+// ValidateUnicastMAC return error if mac is not valid according to ValidateMAC,
+// or it is all-zero, or it is not an unicast address (including broadcast address)
+func ValidateUnicastMAC(mac net.HardwareAddr) error {
+	if err := ValidateMAC(mac); err != nil {
+		return err
+	}
+	if HWAddrtoBig(mac).Sign() == 0 {
+		return fmt.Errorf("%v is all-zero address", mac)
+	}
+	if mac[0]&0x1 != 0 {
+		return fmt.Errorf("%v is not an unicast address", mac)
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+// myaddr_test
+package myaddr
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+)
+
+type testMACRangeCase struct {
+	start          string
+	count          int
+	step           int64
+	expectedResult []string
+	shouldFail     bool
+}
+
+func TestMACRange(t *testing.T) {
+	testData := []testMACRangeCase{
+		{
+			start:          "00:00:00:00:00:fe",
+			count:          3,
+			step:           1,
+			expectedResult: []string{"00:00:00:00:00:fe", "00:00:00:00:00:ff", "00:00:00:00:01:00"},
+		},
+		{
+			start:          "11:22:33:44:55:66",
+			count:          2,
+			step:           -0x100,
+			expectedResult: []string{"11:22:33:44:55:66", "11:22:33:44:54:66"},
+		},
+		{
+			start:          "11:22:33:44:55:66",
+			count:          0,
+			step:           1,
+			expectedResult: []string{},
+		},
+		{
+			start:      "ff:ff:ff:ff:ff:fe",
+			count:      3,
+			step:       1,
+			shouldFail: true,
+		},
+		{
+			start:      "11:22:33:44:55:66",
+			count:      -1,
+			step:       1,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testMACRangeCase) error {
+		start, err := net.ParseMAC(c.start)
+		if err != nil {
+			return err
+		}
+		r, err := MACRange(start, c.count, big.NewInt(c.step))
+		if err != nil {
+			return err
+		}
+		if len(r) != len(c.expectedResult) {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedResult)
+		}
+		for i := range r {
+			if len(r[i]) != 6 || r[i].String() != c.expectedResult[i] {
+				return fmt.Errorf("result %v is different from expected %v", r, c.expectedResult)
+			}
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testMACInOUICase struct {
+	oui            []byte
+	index          *big.Int
+	expectedResult string
+	shouldFail     bool
+}
+
+func TestMACInOUI(t *testing.T) {
+	testData := []testMACInOUICase{
+		{
+			oui:            []byte{0x00, 0x11, 0x22},
+			index:          big.NewInt(0),
+			expectedResult: "00:11:22:00:00:00",
+		},
+		{
+			oui:            []byte{0x00, 0x11, 0x22},
+			index:          big.NewInt(0x10203),
+			expectedResult: "00:11:22:01:02:03",
+		},
+		{
+			oui:            []byte{0x00, 0x11, 0x22},
+			index:          big.NewInt(0xffffff),
+			expectedResult: "00:11:22:ff:ff:ff",
+		},
+		{
+			oui:        []byte{0x00, 0x11, 0x22},
+			index:      big.NewInt(0x1000000),
+			shouldFail: true,
+		},
+		{
+			oui:        []byte{0x00, 0x11, 0x22},
+			index:      big.NewInt(-1),
+			shouldFail: true,
+		},
+		{
+			oui:        []byte{0x00, 0x11},
+			index:      big.NewInt(1),
+			shouldFail: true,
+		},
+		{
+			oui:        []byte{0x00, 0x11, 0x22},
+			index:      nil,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testMACInOUICase) error {
+		r, err := MACInOUI(c.oui, c.index)
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expectedResult {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedResult)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testMACsByOUICase struct {
+	start      string
+	count      int
+	step       int64
+	expected   map[string]string
+	shouldFail bool
+}
+
+func TestMACsByOUI(t *testing.T) {
+	testData := []testMACsByOUICase{
+		{
+			start: "00:11:22:ff:ff:fe",
+			count: 4,
+			step:  1,
+			expected: map[string]string{
+				"00:11:22": "[00:11:22:ff:ff:fe 00:11:22:ff:ff:ff]",
+				"00:11:23": "[00:11:23:00:00:00 00:11:23:00:00:01]",
+			},
+		},
+		{
+			start: "00:11:22:00:00:00",
+			count: 3,
+			step:  0x1000000,
+			expected: map[string]string{
+				"00:11:22": "[00:11:22:00:00:00]",
+				"00:11:23": "[00:11:23:00:00:00]",
+				"00:11:24": "[00:11:24:00:00:00]",
+			},
+		},
+		{
+			start:    "00:11:22:00:00:00",
+			count:    0,
+			step:     1,
+			expected: map[string]string{},
+		},
+		{
+			start:      "ff:ff:ff:ff:ff:fe",
+			count:      3,
+			step:       1,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testMACsByOUICase) error {
+		start, err := net.ParseMAC(c.start)
+		if err != nil {
+			return err
+		}
+		r, err := MACsByOUI(start, c.count, big.NewInt(c.step))
+		if err != nil {
+			return err
+		}
+		if len(r) != len(c.expected) {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		for oui, macs := range r {
+			if fmt.Sprint(macs) != c.expected[oui] {
+				return fmt.Errorf("MACs %v of OUI %v is different from expected %v", macs, oui, c.expected[oui])
+			}
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testIncUnicastMACCase struct {
+	mac         string
+	step        int64
+	expectedMAC string
+	shouldFail  bool
+}
+
+func TestIncUnicastMAC(t *testing.T) {
+	testData := []testIncUnicastMACCase{
+		{mac: "00:11:22:33:44:55", step: 1, expectedMAC: "00:11:22:33:44:56"},
+		//01:xx, 02:xx and 03:xx are skipped
+		{mac: "00:ff:ff:ff:ff:ff", step: 1, expectedMAC: "04:00:00:00:00:00"},
+		{mac: "00:ff:ff:ff:ff:fe", step: 3, expectedMAC: "04:00:00:00:00:01"},
+		{mac: "04:00:00:00:00:00", step: -1, expectedMAC: "00:ff:ff:ff:ff:ff"},
+		{mac: "04:00:00:00:00:01", step: -3, expectedMAC: "00:ff:ff:ff:ff:fe"},
+		{mac: "05:00:00:00:00:00", step: -1, expectedMAC: "04:ff:ff:ff:ff:ff"},
+		//step bigger than a first byte block
+		{mac: "00:00:00:00:00:01", step: 0x10000000000, expectedMAC: "04:00:00:00:00:01"},
+		{mac: "00:11:22:33:44:55", step: 0, expectedMAC: "00:11:22:33:44:55"},
+		{mac: "fc:ff:ff:ff:ff:ff", step: 1, shouldFail: true},
+		{mac: "00:00:00:00:00:01", step: -1, shouldFail: true},
+		{mac: "01:00:00:00:00:00", step: 0, shouldFail: true},
+	}
+	runTest := func(c testIncUnicastMACCase) error {
+		mac, err := net.ParseMAC(c.mac)
+		if err != nil {
+			return err
+		}
+		r, err := IncUnicastMAC(mac, big.NewInt(c.step))
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expectedMAC {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedMAC)
+		}
+		if err := ValidateUnicastMAC(r); err != nil {
+			return err
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestFormatMAC(t *testing.T) {
+	mac, _ := net.ParseMAC("00:1a:2b:3c:4d:5e")
+	testData := []struct {
+		sep      byte
+		upper    bool
+		expected string
+	}{
+		{':', false, "00:1a:2b:3c:4d:5e"},
+		{':', true, "00:1A:2B:3C:4D:5E"},
+		{'-', true, "00-1A-2B-3C-4D-5E"},
+		{'.', false, "001a.2b3c.4d5e"},
+		{'.', true, "001A.2B3C.4D5E"},
+		{0, false, "001a2b3c4d5e"},
+	}
+	for _, c := range testData {
+		if s := FormatMAC(mac, c.sep, c.upper); s != c.expected {
+			t.Fatalf("result %v is different from expected %v", s, c.expected)
+		}
+	}
+	if s := FormatMACCisco(mac); s != "001a.2b3c.4d5e" {
+		t.Fatalf("result %v is different from expected 001a.2b3c.4d5e", s)
+	}
+}
+
+type testParseMACFlexibleCase struct {
+	macStr     string
+	shouldFail bool
+}
+
+func TestParseMACFlexible(t *testing.T) {
+	testData := []testParseMACFlexibleCase{
+		{macStr: "0011.2233.4455"},
+		{macStr: "001122334455"},
+		{macStr: "00-11-22-33-44-55"},
+		{macStr: "00:11:22:33:44:55"},
+		{macStr: "00:11:22:33:44:55:66:77", shouldFail: true},
+		{macStr: "0011223344zz", shouldFail: true},
+		{macStr: "0011223344", shouldFail: true},
+		{macStr: "", shouldFail: true},
+	}
+	runTest := func(c testParseMACFlexibleCase) error {
+		mac, err := ParseMACFlexible(c.macStr)
+		if err != nil {
+			return err
+		}
+		if mac.String() != "00:11:22:33:44:55" {
+			return fmt.Errorf("result %v is different from expected 00:11:22:33:44:55", mac)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testValidateMACCase struct {
+	mac        net.HardwareAddr
+	invalid    bool
+	notUnicast bool
+}
+
+func TestValidateMAC(t *testing.T) {
+	testData := []testValidateMACCase{
+		{
+			mac: net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+		},
+		{
+			mac:     net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77},
+			invalid: true,
+		},
+		{
+			mac:     net.HardwareAddr{0x00, 0x11, 0x22, 0x33},
+			invalid: true,
+		},
+		{
+			mac:     nil,
+			invalid: true,
+		},
+		{
+			mac:        net.HardwareAddr{0, 0, 0, 0, 0, 0},
+			notUnicast: true,
+		},
+		{
+			mac:        net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			notUnicast: true,
+		},
+		{
+			mac:        net.HardwareAddr{0x01, 0x00, 0x5e, 0x00, 0x00, 0x01},
+			notUnicast: true,
+		},
+	}
+	for i, c := range testData {
+		err := ValidateMAC(c.mac)
+		if (err != nil) != c.invalid {
+			t.Fatalf("case %d: unexpected ValidateMAC result %v", i, err)
+		}
+		err = ValidateUnicastMAC(c.mac)
+		if (err != nil) != (c.invalid || c.notUnicast) {
+			t.Fatalf("case %d: unexpected ValidateUnicastMAC result %v", i, err)
+		}
+	}
+	if err := ValidateEUI64(net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateEUI64(net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}); err == nil {
+		t.Fatal("6-byte MAC should fail EUI-64 validation")
+	}
+}
@@ -9,10 +9,15 @@ include IP address, MAC address and VLAN ID.
 package myaddr
 
 import (
+	"encoding/binary"
 	"fmt"
 	"math/big"
+	"math/rand"
 	"net"
 	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 // HWAddrtoBig convert hardware address to *big.Int
@@ -39,10 +44,81 @@ func BigtoMACAddr(n *big.Int) (net.HardwareAddr, error) {
 	return BigtoHWAddr(n, 6)
 }
 
+// IsIPv4 return true if addr is an IPv4 address,
+// this includes both 4-byte form and IPv4-mapped IPv6 form (::ffff:a.b.c.d),
+// since net.ParseIP returns the latter for an IPv4 address string
+func IsIPv4(addr net.IP) bool {
+	return addr.To4() != nil
+}
+
+// IsIPv6 return true if addr is a 16-byte IPv6 address that is not
+// an IPv4-mapped IPv6 address
+func IsIPv6(addr net.IP) bool {
+	return len(addr) == net.IPv6len && !IsIPv4(addr)
+}
+
+// Family return 4 if addr is an IPv4 address, 6 if addr is an IPv6 address,
+// 0 if addr is invalid; see IsIPv4 and IsIPv6 for detail
+func Family(addr net.IP) int {
+	switch {
+	case IsIPv4(addr):
+		return 4
+	case IsIPv6(addr):
+		return 6
+	}
+	return 0
+}
+
+// ToNetip convert ip to netip.Addr, return false if ip is invalid.
+// an IPv4 address (either 4-byte form or IPv4-mapped IPv6 form) is always
+// converted to an IPv4 netip.Addr (Is4() returns true), not an IPv4-mapped IPv6 one
+func ToNetip(ip net.IP) (netip.Addr, bool) {
+	if IsIPv4(ip) {
+		return netip.AddrFromSlice(ip.To4())
+	}
+	if IsIPv6(ip) {
+		return netip.AddrFromSlice(ip)
+	}
+	return netip.Addr{}, false
+}
+
+// ToNetIP convert addr to net.IP, an IPv4 addr is converted to 4-byte form;
+// the zone of addr is dropped since net.IP doesn't have zone.
+// return nil if addr is invalid
+func ToNetIP(addr netip.Addr) net.IP {
+	if !addr.IsValid() {
+		return nil
+	}
+	return net.IP(addr.AsSlice())
+}
+
+// IPsToAddrs convert each of ips to netip.Addr with ToNetip,
+// return error with the index of first invalid one
+func IPsToAddrs(ips []net.IP) ([]netip.Addr, error) {
+	r := make([]netip.Addr, len(ips))
+	for i, ip := range ips {
+		addr, ok := ToNetip(ip)
+		if !ok {
+			return nil, fmt.Errorf("invalid address %v at index %d", ip, i)
+		}
+		r[i] = addr
+	}
+	return r, nil
+}
+
+// AddrsToIPs convert each of addrs to net.IP with ToNetIP, an invalid one is converted to nil
+func AddrsToIPs(addrs []netip.Addr) []net.IP {
+	r := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		r[i] = ToNetIP(addr)
+	}
+	return r
+}
+
 // AddrtoBig convert IP address to *big.Int
 func AddrtoBig(addr net.IP) *big.Int {
 	r := new(big.Int)
-	if addr.To4() != nil {
+	if IsIPv4(addr) {
 		r.SetBytes(addr.To4()[:4])
 	} else {
 		r.SetBytes(addr.To16()[:16])
@@ -51,6 +127,18 @@ func AddrtoBig(addr net.IP) *big.Int {
 	return r
 }
 
+// ParseAddrToBig parse address string s, return its value as *big.Int,
+// along with bit length of the address: 32 for IPv4, 128 for IPv6.
+// an IPv4-mapped IPv6 address is treated as IPv4 address, same as AddrtoBig
+func ParseAddrToBig(s string) (n *big.Int, bits int, err error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse %v as an IP address, %w", s, err)
+	}
+	addr = addr.Unmap()
+	return new(big.Int).SetBytes(addr.AsSlice()), addr.BitLen(), nil
+}
+
 // BigtoAddr convert n to IPv4 address if ipv4 is true, IPv6 address otherwise
 func BigtoAddr(n *big.Int, ipv4 bool) (net.IP, error) {
 	buf := n.Bytes()
@@ -81,6 +169,28 @@ const (
 	MaxIPv6AddrStr = "340282366920938463463374607431768211455"
 )
 
+// ParseStep parse step string s into *big.Int, s is a signed decimal or hex (with 0x prefix) integer,
+// like "256", "+256", "-10", "0x100" or "-0x10"; it could be arbitrary large
+func ParseStep(s string) (*big.Int, error) {
+	numstr := strings.TrimLeft(s, "+-")
+	if len(s)-len(numstr) > 1 {
+		return nil, fmt.Errorf("invalid step %v", s)
+	}
+	base := 10
+	if strings.HasPrefix(numstr, "0x") || strings.HasPrefix(numstr, "0X") {
+		base = 16
+		numstr = numstr[2:]
+	}
+	r, ok := new(big.Int).SetString(numstr, base)
+	if !ok || strings.ContainsAny(numstr, "+-") {
+		return nil, fmt.Errorf("invalid step %v", s)
+	}
+	if strings.HasPrefix(s, "-") {
+		r.Neg(r)
+	}
+	return r, nil
+}
+
 // IncMACAddr increase macaddr by step (could be negative), return the result
 func IncMACAddr(macaddr net.HardwareAddr, step *big.Int) (net.HardwareAddr, error) {
 	rn := big.NewInt(0).Add(HWAddrtoBig(macaddr), step)
@@ -100,7 +210,7 @@ func IncAddr(addr net.IP, step *big.Int) (net.IP, error) {
 	if rn.Cmp(big.NewInt(0)) == -1 {
 		return nil, fmt.Errorf("%v and step %d result in negative result", addr, step)
 	}
-	if addr.To4() != nil {
+	if IsIPv4(addr) {
 		//ipv4
 		if rn.Cmp(big.NewInt(MaxIPv4AddrN)) == 1 {
 			return nil, fmt.Errorf("%v and step %d result exceeds 255.255.255.255", addr, step)
@@ -115,6 +225,164 @@ func IncAddr(addr net.IP, step *big.Int) (net.IP, error) {
 	return BigtoAddr(rn, false)
 }
 
+// IncAddrInto increase addr by step (could be negative), write the result into dst,
+// which avoids allocating a new net.IP for the result;
+// dst must be 4 or 16 bytes long if addr is an IPv4 address, an IPv4 result is written
+// in IPv4-mapped IPv6 form into a 16-byte dst; dst must be 16 bytes long if addr is an IPv6 address.
+// dst could be addr itself, e.g. a net.IP returned by net.ParseIP
+func IncAddrInto(dst, addr net.IP, step *big.Int) error {
+	var alen int
+	switch Family(addr) {
+	case 4:
+		alen = net.IPv4len
+		if len(dst) != net.IPv4len && len(dst) != net.IPv6len {
+			return fmt.Errorf("dst length %d is not %d or %d", len(dst), net.IPv4len, net.IPv6len)
+		}
+	case 6:
+		alen = net.IPv6len
+		if len(dst) != net.IPv6len {
+			return fmt.Errorf("dst length %d is not %d", len(dst), net.IPv6len)
+		}
+	default:
+		return fmt.Errorf("invalid IP address %v", addr)
+	}
+	rn := AddrtoBig(addr)
+	rn.Add(rn, step)
+	if rn.Sign() < 0 {
+		return fmt.Errorf("%v and step %d result in negative result", addr, step)
+	}
+	if rn.BitLen() > alen*8 {
+		return fmt.Errorf("%v and step %d result exceeds max address", addr, step)
+	}
+	if len(dst) != alen {
+		//IPv4 result into 16-byte dst
+		copy(dst, net.IPv4(0, 0, 0, 0)[:12])
+		rn.FillBytes(dst[12:])
+		return nil
+	}
+	rn.FillBytes(dst)
+	return nil
+}
+
+// IncMACAddrInto increase macaddr by step (could be negative), write the result into dst,
+// which avoids allocating a new net.HardwareAddr for the result;
+// macaddr and dst must be 6 bytes long, dst could be macaddr itself
+func IncMACAddrInto(dst, macaddr net.HardwareAddr, step *big.Int) error {
+	if len(macaddr) != 6 {
+		return fmt.Errorf("%v is not a 6-byte MAC address", macaddr)
+	}
+	if len(dst) != 6 {
+		return fmt.Errorf("dst length %d is not 6", len(dst))
+	}
+	rn := HWAddrtoBig(macaddr)
+	rn.Add(rn, step)
+	if rn.Sign() < 0 {
+		return fmt.Errorf("%v and step %d result in negative result", macaddr, step)
+	}
+	if rn.BitLen() > 48 {
+		return fmt.Errorf("%v and step %d result exceeds FF:FF:FF:FF:FF:FF", macaddr, step)
+	}
+	rn.FillBytes(dst)
+	return nil
+}
+
+// IncAddrWrapRange increase addr by step (could be negative) within range [low, high],
+// the result wraps around to the other end if it goes beyond low or high;
+// addr, low and high must be in same address family, and addr must be in the range
+func IncAddrWrapRange(addr net.IP, step *big.Int, low, high net.IP) (net.IP, error) {
+	f := Family(addr)
+	if f == 0 || f != Family(low) || f != Family(high) {
+		return nil, fmt.Errorf("%v, %v and %v are not valid addresses in same address family", addr, low, high)
+	}
+	lown := AddrtoBig(low)
+	highn := AddrtoBig(high)
+	addrn := AddrtoBig(addr)
+	if lown.Cmp(highn) > 0 {
+		return nil, fmt.Errorf("low %v is bigger than high %v", low, high)
+	}
+	if addrn.Cmp(lown) < 0 || addrn.Cmp(highn) > 0 {
+		return nil, fmt.Errorf("%v is not in range %v-%v", addr, low, high)
+	}
+	size := new(big.Int).Sub(highn, lown)
+	size.Add(size, big.NewInt(1))
+	offset := new(big.Int).Sub(addrn, lown)
+	offset.Add(offset, step)
+	offset.Mod(offset, size)
+	return BigtoAddr(offset.Add(offset, lown), f == 4)
+}
+
+// IncAddrWatchBoundary increase addr by step (could be negative) like IncAddr,
+// crossed is true if result is in a different prefix of length boundaryBits than addr,
+// e.g. boundaryBits 24 reports crossing into another /24
+func IncAddrWatchBoundary(addr net.IP, step *big.Int, boundaryBits int) (result net.IP, crossed bool, err error) {
+	bitlen := 128
+	switch Family(addr) {
+	case 4:
+		bitlen = 32
+	case 0:
+		return nil, false, fmt.Errorf("invalid IP address %v", addr)
+	}
+	if boundaryBits < 0 || boundaryBits > bitlen {
+		return nil, false, fmt.Errorf("invalid boundary prefix length %d for %v", boundaryBits, addr)
+	}
+	result, err = IncAddr(addr, step)
+	if err != nil {
+		return nil, false, err
+	}
+	hostbits := uint(bitlen - boundaryBits)
+	before := new(big.Int).Rsh(AddrtoBig(addr), hostbits)
+	after := new(big.Int).Rsh(AddrtoBig(result), hostbits)
+	return result, before.Cmp(after) != 0, nil
+}
+
+// IncUntil return addresses start, start+step, start+2*step ... that are smaller than stop,
+// step must be positive; start and stop must be in same address family, and start <= stop.
+// all addresses are held in the returned slice, for a big range with a small step,
+// use AddrPool or IncAddr to generate addresses one by one instead
+func IncUntil(start net.IP, stop net.IP, step *big.Int) ([]net.IP, error) {
+	f := Family(start)
+	if f == 0 || f != Family(stop) {
+		return nil, fmt.Errorf("%v and %v are not valid addresses in same address family", start, stop)
+	}
+	if step.Sign() <= 0 {
+		return nil, fmt.Errorf("step %d is not positive", step)
+	}
+	cur := AddrtoBig(start)
+	stopn := AddrtoBig(stop)
+	if cur.Cmp(stopn) > 0 {
+		return nil, fmt.Errorf("start %v is bigger than stop %v", start, stop)
+	}
+	r := []net.IP{}
+	for ; cur.Cmp(stopn) < 0; cur.Add(cur, step) {
+		addr, err := BigtoAddr(cur, f == 4)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, addr)
+	}
+	return r, nil
+}
+
+// IncIPv4 increase IPv4 addr by step (could be negative), return the result;
+// it is a faster alternative to IncAddr for IPv4 address, without using big.Int
+func IncIPv4(addr netip.Addr, step int64) (netip.Addr, error) {
+	if !addr.Is4() {
+		return netip.Addr{}, fmt.Errorf("%v is not an IPv4 address", addr)
+	}
+	if step > MaxIPv4AddrN || step < -MaxIPv4AddrN {
+		return netip.Addr{}, fmt.Errorf("%v and step %d result exceeds IPv4 address range", addr, step)
+	}
+	n, _ := IPv4ToUint32(addr)
+	rn := int64(n) + step
+	if rn < 0 {
+		return netip.Addr{}, fmt.Errorf("%v and step %d result in negative result", addr, step)
+	}
+	if rn > MaxIPv4AddrN {
+		return netip.Addr{}, fmt.Errorf("%v and step %d result exceeds 255.255.255.255", addr, step)
+	}
+	return Uint32ToIPv4(uint32(rn)), nil
+}
+
 // GenAddrWithIPNet geneate an address = prefix + hostn.
 // hostn must>=0
 func GenAddrWithIPNet(prefix *net.IPNet, hostn *big.Int) (net.IP, error) {
@@ -129,9 +397,90 @@ func GenAddrWithIPNet(prefix *net.IPNet, hostn *big.Int) (net.IP, error) {
 	return IncAddr(prefix.IP, hostn)
 }
 
+// HostIndexInIPNet return the host offset of addr in n, i.e. addr - network address of n,
+// it is the reverse of GenAddrWithIPNet; return error if addr is not in n
+func HostIndexInIPNet(n *net.IPNet, addr net.IP) (*big.Int, error) {
+	if n == nil || !n.Contains(addr) {
+		return nil, fmt.Errorf("%v is not in %v", addr, n)
+	}
+	r := AddrtoBig(addr)
+	return r.Sub(r, AddrtoBig(n.IP.Mask(n.Mask))), nil
+}
+
+// MaskToPrefixLen return the prefix length of mask,
+// return error if mask is not a valid IPv4/IPv6 mask or is non-contiguous
+func MaskToPrefixLen(mask net.IPMask) (int, error) {
+	if len(mask) != net.IPv4len && len(mask) != net.IPv6len {
+		return 0, fmt.Errorf("invalid mask length %d", len(mask))
+	}
+	ones, bits := mask.Size()
+	if bits == 0 {
+		return 0, fmt.Errorf("%v is not a contiguous mask", mask)
+	}
+	return ones, nil
+}
+
+// PrefixLenToMask return a IPv4 mask if ipv4 is true, IPv6 mask otherwise,
+// with bits leading ones
+func PrefixLenToMask(bits int, ipv4 bool) (net.IPMask, error) {
+	totalbits := 128
+	if ipv4 {
+		totalbits = 32
+	}
+	if bits < 0 || bits > totalbits {
+		return nil, fmt.Errorf("invalid prefix length %d for %d bit mask", bits, totalbits)
+	}
+	return net.CIDRMask(bits, totalbits), nil
+}
+
+// PrefixToIPNet convert prefix to *net.IPNet, host bits of prefix are cleared;
+// IP and Mask of the result are 4-byte long for IPv4 prefix.
+// return nil if prefix is invalid
+func PrefixToIPNet(prefix netip.Prefix) *net.IPNet {
+	if !prefix.IsValid() {
+		return nil
+	}
+	prefix = prefix.Masked()
+	return &net.IPNet{
+		IP:   ToNetIP(prefix.Addr()),
+		Mask: net.CIDRMask(prefix.Bits(), prefix.Addr().BitLen()),
+	}
+}
+
+// IPNetToPrefix convert n to netip.Prefix, return error if n.IP is invalid,
+// or n.Mask is non-contiguous or doesn't match the address family of n.IP
+func IPNetToPrefix(n *net.IPNet) (netip.Prefix, error) {
+	if n == nil {
+		return netip.Prefix{}, fmt.Errorf("IPNet is nil")
+	}
+	addr, ok := ToNetip(n.IP)
+	if !ok {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %v", n.IP)
+	}
+	bits, err := MaskToPrefixLen(n.Mask)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if addr.Is4() && len(n.Mask) == net.IPv6len {
+		//IPv4 mask in 16-byte form
+		if bits < 96 {
+			return netip.Prefix{}, fmt.Errorf("mask %v is not valid for IPv4 address %v", n.Mask, n.IP)
+		}
+		bits -= 96
+	}
+	if addr.Is6() && len(n.Mask) != net.IPv6len {
+		return netip.Prefix{}, fmt.Errorf("mask %v is not valid for IPv6 address %v", n.Mask, n.IP)
+	}
+	return netip.PrefixFrom(addr, bits).Masked(), nil
+}
+
 // GenPrefixWithPrefix geneate an prefix = prefix + hostn.
-// hostn must>=0
+// hostn must>=0; the result keeps address family of prefix,
+// an IPv4-mapped IPv6 prefix results in an IPv4-mapped IPv6 prefix, not an IPv4 one
 func GenPrefixWithPrefix(prefix netip.Prefix, hostn *big.Int) (netip.Prefix, error) {
+	if !prefix.IsValid() {
+		return netip.Prefix{}, fmt.Errorf("invalid prefix %v", prefix)
+	}
 	if hostn.Cmp(big.NewInt(0)) == -1 {
 		return netip.Prefix{}, fmt.Errorf("%v is negative", hostn)
 	}
@@ -141,71 +490,221 @@ func GenPrefixWithPrefix(prefix netip.Prefix, hostn *big.Int) (netip.Prefix, err
 	if hostn.Cmp(deltan) >= 0 {
 		return netip.Prefix{}, fmt.Errorf("%v exceeds max allowed host value for prefix %v", hostn, prefix)
 	}
-	rip, err := IncAddr(prefix.Masked().Addr().AsSlice(), hostn)
+	rn := new(big.Int).Add(netipToBig(prefix.Masked().Addr()), hostn)
+	r, err := bigToNetip(rn, totalmaskbits)
 	if err != nil {
 		return netip.Prefix{}, err
 	}
-	r, ok := netip.AddrFromSlice(rip)
-	if !ok {
-		return netip.Prefix{}, fmt.Errorf("invalid result address, %v", rip)
-	}
+	return netip.PrefixFrom(r, prefix.Bits()), nil
+}
 
-	return netip.PrefixFrom(r, prefix.Bits()), err
+// GenAddrWithStructuredHost geneate an address = prefix + host index packed from fields,
+// each fields[i] takes widths[i] bits, fields[0] is the most significant one, e.g.
+// fields {3, 5} with widths {4, 8} is host index 0x305;
+// each width must be in range [1, 64], fields[i] must fit into widths[i] bits,
+// and sum of widths must not exceed number of host bits of prefix
+func GenAddrWithStructuredHost(prefix netip.Prefix, fields []uint, widths []int) (netip.Addr, error) {
+	if !prefix.IsValid() {
+		return netip.Addr{}, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	if len(fields) != len(widths) {
+		return netip.Addr{}, fmt.Errorf("number of fields %d is different from number of widths %d", len(fields), len(widths))
+	}
+	hostn := big.NewInt(0)
+	total := 0
+	for i, w := range widths {
+		if w < 1 || w > 64 {
+			return netip.Addr{}, fmt.Errorf("invalid width %d of field %d", w, i)
+		}
+		if w < 64 && uint64(fields[i])>>w != 0 {
+			return netip.Addr{}, fmt.Errorf("field %d value %d exceeds %d bits", i, fields[i], w)
+		}
+		total += w
+		hostn.Lsh(hostn, uint(w))
+		hostn.Or(hostn, new(big.Int).SetUint64(uint64(fields[i])))
+	}
+	if hostbits := prefix.Addr().BitLen() - prefix.Bits(); total > hostbits {
+		return netip.Addr{}, fmt.Errorf("sum of widths %d exceeds %d host bits of %v", total, hostbits, prefix)
+	}
+	r, err := GenPrefixWithPrefix(prefix, hostn)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return r.Addr(), nil
 }
 
 // GenConnectionAddrStr return a string with following format:
 // IPv4: <prefix><ip>:<port>
 // IPv6: <prefix>[<ip>]:<port>
 func GenConnectionAddrStr(prefix string, ip net.IP, port int) string {
-	if ip.To4() != nil {
+	if IsIPv4(ip) {
 		return fmt.Sprintf("%v%v:%v", prefix, ip, port)
 	}
 	return fmt.Sprintf("%v[%v]:%v", prefix, ip, port)
 }
 
-// IncreaseVLANIDs increase a slice of VLAN Id (12 bit long) with specified step
-func IncreaseVLANIDs(ids []uint16, step int) ([]uint16, error) {
-	if len(ids) == 0 {
-		return ids, nil
+// GenConnectionURL return an URL string with following format:
+// <scheme>://<host>:<port><path>
+// host could be an IP address or a DNS hostname, an IPv6 address is enclosed in [];
+// path is optional, a leading "/" is added if it is missing
+func GenConnectionURL(scheme, host string, port int, path string) (string, error) {
+	if scheme == "" {
+		return "", fmt.Errorf("scheme is empty")
 	}
-	bigstr := ""
-	for i := 0; i < len(ids); i++ {
-		if ids[i] > 0xfff {
-			return []uint16{}, fmt.Errorf("invalid VLAN id %d", ids[i])
-		}
-		s := big.NewInt(int64(ids[i])).Text(16)
-		for i := 0; i < len(s)%3; i++ {
-			s = "0" + s
-		}
-		bigstr += s
-	}
-	all := big.NewInt(0)
-	if _, ok := all.SetString(bigstr, 16); !ok {
-		return []uint16{}, fmt.Errorf("failed to increase, possible invaliud VLAN IDs %v", ids)
-	}
-	all.Add(all, big.NewInt(int64(step)))
-	newbigstr := all.Text(16)
-	for i := 0; i < len(newbigstr)%3; i++ {
-		newbigstr = "0" + newbigstr
-	}
-	r := []uint16{}
-	for i := 0; i < len(newbigstr); i += 3 {
-		newv := big.NewInt(0)
-		if _, ok := newv.SetString(newbigstr[i:i+3], 16); !ok {
-			return []uint16{}, fmt.Errorf("failed conver a hex str to int, %v", newbigstr[i:i+3])
-		}
-		r = append(r, uint16(newv.Int64()))
+	if host == "" {
+		return "", fmt.Errorf("host is empty")
+	}
+	if port <= 0 || port > 65535 {
+		return "", fmt.Errorf("invalid port %d", port)
+	}
+	hoststr := host
+	if addr, err := netip.ParseAddr(host); err == nil {
+		hoststr = addr.String()
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u := &url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(hoststr, strconv.Itoa(port)),
+		Path:   path,
+	}
+	r := u.String()
+	if _, err := url.Parse(r); err != nil {
+		return "", fmt.Errorf("failed to generate a valid URL, %w", err)
 	}
 	return r, nil
 }
 
-// GetLLAFromMac return an IPv6 link local address from mac,
+// IncreaseVLANIDs increase a slice of VLAN Id (12 bit long) with specified step
+func IncreaseVLANIDs(ids []uint16, step int) ([]uint16, error) {
+	return IncreaseTags(ids, step, 12)
+}
+
+// macToEUI64 return the modified EUI-64 interface identifier of 6-byte mac,
 // based on Appendix A of RFC4291
-func GetLLAFromMac(mac net.HardwareAddr) net.IP {
+func macToEUI64(mac net.HardwareAddr) ([8]byte, error) {
 	var ifid [8]byte
+	if len(mac) != 6 {
+		return ifid, fmt.Errorf("%v is not a 6-byte MAC address", mac)
+	}
 	ifid[0] = mac[0] ^ 0b00000010
 	copy(ifid[1:3], mac[1:3])
 	copy(ifid[3:5], []byte{0xff, 0xfe})
 	copy(ifid[5:], mac[3:6])
-	return net.IP(append([]byte{0xfe, 0x80, 0, 0, 0, 0, 0, 0}, ifid[:]...))
+	return ifid, nil
+}
+
+// GetLLAFromMac return an IPv6 link local address from mac,
+// based on Appendix A of RFC4291; mac must be 6 bytes long
+func GetLLAFromMac(mac net.HardwareAddr) (net.IP, error) {
+	ifid, err := macToEUI64(mac)
+	if err != nil {
+		return nil, err
+	}
+	return net.IP(append([]byte{0xfe, 0x80, 0, 0, 0, 0, 0, 0}, ifid[:]...)), nil
+}
+
+// SubnetRouterAnycast return the Subnet-Router anycast address of IPv6 prefix,
+// which is the prefix with all host bits zero, based on section 2.6.1 of RFC4291
+func SubnetRouterAnycast(prefix netip.Prefix) (netip.Addr, error) {
+	if !prefix.IsValid() || !prefix.Addr().Is6() {
+		return netip.Addr{}, fmt.Errorf("%v is not an IPv6 prefix", prefix)
+	}
+	return prefix.Masked().Addr(), nil
+}
+
+// GenStableAddr return an address = IPv6 /64 prefix + 64-bit interface identifier iid
+func GenStableAddr(prefix netip.Prefix, iid [8]byte) (netip.Addr, error) {
+	if !prefix.Addr().Is6() || prefix.Bits() != 64 {
+		return netip.Addr{}, fmt.Errorf("%v is not an IPv6 /64 prefix", prefix)
+	}
+	a16 := prefix.Addr().As16()
+	copy(a16[8:], iid[:])
+	return netip.AddrFrom16(a16), nil
+}
+
+// AddrsWithSuffix return the address of interface identifier iid in each of IPv6 /64 prefixes, see GenStableAddr
+func AddrsWithSuffix(prefixes []netip.Prefix, iid [8]byte) ([]netip.Addr, error) {
+	r := make([]netip.Addr, 0, len(prefixes))
+	for i, prefix := range prefixes {
+		addr, err := GenStableAddr(prefix, iid)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prefix at index %d, %w", i, err)
+		}
+		r = append(r, addr)
+	}
+	return r, nil
+}
+
+// RandomIID return a random 64-bit interface identifier generated by rng,
+// with universal/local bit cleared as it is not derived from a universal MAC address (RFC4941);
+// top-level functions of math/rand are used if rng is nil
+func RandomIID(rng *rand.Rand) [8]byte {
+	var n uint64
+	if rng == nil {
+		n = rand.Uint64()
+	} else {
+		n = rng.Uint64()
+	}
+	var iid [8]byte
+	binary.BigEndian.PutUint64(iid[:], n)
+	iid[0] &^= 0b00000010
+	return iid
+}
+
+// GenSLAACAddr return the SLAAC address that a host with mac autoconfigures in IPv6 /64 prefix,
+// using modified EUI-64 interface identifier; mac must be 6 bytes long
+func GenSLAACAddr(prefix netip.Prefix, mac net.HardwareAddr) (netip.Addr, error) {
+	ifid, err := macToEUI64(mac)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return GenStableAddr(prefix, ifid)
+}
+
+// SLAACPrefixPlan return the SLAAC address of each of macs in IPv6 /64 globalPrefix,
+// see GenSLAACAddr; the key of returned map is the string form of MAC address
+func SLAACPrefixPlan(globalPrefix netip.Prefix, macs []net.HardwareAddr) (map[string]netip.Addr, error) {
+	r := make(map[string]netip.Addr, len(macs))
+	for i, mac := range macs {
+		addr, err := GenSLAACAddr(globalPrefix, mac)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate SLAAC address for MAC address at index %d, %w", i, err)
+		}
+		r[mac.String()] = addr
+	}
+	return r, nil
+}
+
+// MacFromLLA return the MAC address embedded in IPv6 link local address lla,
+// it is the reverse of GetLLAFromMac; return error if lla is not in fe80::/64,
+// or its interface identifier is not a modified EUI-64 derived from a MAC address
+func MacFromLLA(lla netip.Addr) (net.HardwareAddr, error) {
+	if !lla.Is6() || !netip.PrefixFrom(netip.AddrFrom16([16]byte{0xfe, 0x80}), 64).Contains(lla.WithZone("")) {
+		return nil, fmt.Errorf("%v is not in fe80::/64", lla)
+	}
+	a16 := lla.As16()
+	if a16[11] != 0xff || a16[12] != 0xfe {
+		return nil, fmt.Errorf("%v doesn't contain a MAC address based interface identifier", lla)
+	}
+	mac := make(net.HardwareAddr, 6)
+	mac[0] = a16[8] ^ 0b00000010
+	copy(mac[1:3], a16[9:11])
+	copy(mac[3:], a16[13:])
+	return mac, nil
+}
+
+// GetLLAsFromMacs return an IPv6 link local address for each of macs, see GetLLAFromMac;
+// return error with index of the first invalid MAC address
+func GetLLAsFromMacs(macs []net.HardwareAddr) ([]net.IP, error) {
+	r := make([]net.IP, len(macs))
+	for i, mac := range macs {
+		lla, err := GetLLAFromMac(mac)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAC address at index %d, %w", i, err)
+		}
+		r[i] = lla
+	}
+	return r, nil
 }
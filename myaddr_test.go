@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"math/rand"
 	"net"
 	"net/netip"
 	"strings"
@@ -316,6 +317,14 @@ func TestGenAddrWithPrefix(t *testing.T) {
 		if !rip.Equal(net.ParseIP(c.expectedAddr)) {
 			return fmt.Errorf("GenAddrWithIPNet: result addr %v is different from expected addr %v", rip, c.expectedAddr)
 		}
+		//test HostIndexInIPNet
+		hostn, err := HostIndexInIPNet(ipnet, rip)
+		if err != nil {
+			return fmt.Errorf("failed to get host index via HostIndexInIPNet,%v", err)
+		}
+		if hostn.Int64() != c.hostn {
+			return fmt.Errorf("HostIndexInIPNet: result %v is different from expected %d", hostn, c.hostn)
+		}
 		//test GenAddrWithPrefix
 		prefix := netip.MustParsePrefix(c.prefixStr)
 		newprefix, err := GenPrefixWithPrefix(prefix, big.NewInt(c.hostn))
@@ -341,6 +350,24 @@ func TestGenAddrWithPrefix(t *testing.T) {
 			}
 		}
 	}
+	//IPv4-mapped IPv6 prefix keeps its family
+	p4in6, err := GenPrefixWithPrefix(netip.MustParsePrefix("::ffff:10.0.0.0/120"), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p4in6.String() != "::ffff:10.0.0.5/120" {
+		t.Fatalf("GenPrefixWithPrefix: result %v is different from expected ::ffff:10.0.0.5/120", p4in6)
+	}
+	if _, err := GenPrefixWithPrefix(netip.Prefix{}, big.NewInt(0)); err == nil {
+		t.Fatal("GenPrefixWithPrefix should fail for invalid prefix")
+	}
+	_, ipnet, _ := net.ParseCIDR("192.168.1.0/24")
+	if _, err := HostIndexInIPNet(ipnet, net.ParseIP("192.168.2.1")); err == nil {
+		t.Fatal("HostIndexInIPNet should fail for address not in prefix")
+	}
+	if _, err := HostIndexInIPNet(ipnet, net.ParseIP("2001:dead::1")); err == nil {
+		t.Fatal("HostIndexInIPNet should fail for address in different family")
+	}
 }
 
 type testGenConnectionCase struct {
@@ -486,8 +513,1203 @@ func TestIncreaseVLANs(t *testing.T) {
 
 func TestLLA(t *testing.T) {
 	mac, _ := net.ParseMAC("4a:08:5d:b5:91:ed")
-	lla := GetLLAFromMac(mac)
+	lla, err := GetLLAFromMac(mac)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if !lla.Equal(net.ParseIP("fe80::4808:5dff:feb5:91ed")) {
 		t.Fatalf("result LLA %v is different from expect %v", lla, "fe80::4808:5dff:feb5:91ed")
 	}
+	for _, mac := range []net.HardwareAddr{{0x4a, 0x08, 0x5d, 0xb5}, {0x4a, 0x08, 0x5d, 0xb5, 0x91, 0xed, 0x01, 0x02}, nil} {
+		if _, err := GetLLAFromMac(mac); err == nil {
+			t.Fatalf("%d-byte mac should fail", len(mac))
+		}
+	}
+	rmac, err := MacFromLLA(netip.MustParseAddr("fe80::4808:5dff:feb5:91ed%eth0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rmac.String() != mac.String() {
+		t.Fatalf("result mac %v is different from expected %v", rmac, mac)
+	}
+	for _, addr := range []string{"fe80::4808:5dff:fdb5:91ed", "fe80:1::4808:5dff:feb5:91ed", "2001:dead::4808:5dff:feb5:91ed", "1.1.1.1"} {
+		if _, err := MacFromLLA(netip.MustParseAddr(addr)); err == nil {
+			t.Fatalf("getting mac from %v should fail", addr)
+		}
+	}
+}
+
+type testMaskCase struct {
+	maskStr    string
+	bits       int
+	ipv4       bool
+	shouldFail bool
+}
+
+func TestMaskPrefixLen(t *testing.T) {
+	testData := []testMaskCase{
+		{
+			maskStr: "255.255.255.0",
+			bits:    24,
+			ipv4:    true,
+		},
+		{
+			maskStr: "0.0.0.0",
+			bits:    0,
+			ipv4:    true,
+		},
+		{
+			maskStr: "255.255.255.255",
+			bits:    32,
+			ipv4:    true,
+		},
+		{
+			maskStr: "ffff:ffff:ffff:ffff::",
+			bits:    64,
+		},
+		{
+			maskStr:    "255.0.255.0",
+			ipv4:       true,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testMaskCase) error {
+		var mask net.IPMask
+		if c.ipv4 {
+			mask = net.IPMask(net.ParseIP(c.maskStr).To4())
+		} else {
+			mask = net.IPMask(net.ParseIP(c.maskStr).To16())
+		}
+		bits, err := MaskToPrefixLen(mask)
+		if err != nil {
+			return err
+		}
+		if bits != c.bits {
+			return fmt.Errorf("result prefix length %d is different from expected %d", bits, c.bits)
+		}
+		rmask, err := PrefixLenToMask(bits, c.ipv4)
+		if err != nil {
+			return err
+		}
+		if rmask.String() != mask.String() {
+			return fmt.Errorf("result mask %v is different from expected %v", rmask, mask)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if _, err := PrefixLenToMask(33, true); err == nil {
+		t.Fatal("prefix length 33 should fail for IPv4")
+	}
+}
+
+func TestFamily(t *testing.T) {
+	testData := []struct {
+		addr   net.IP
+		family int
+	}{
+		{
+			addr:   net.ParseIP("192.168.1.1"),
+			family: 4,
+		},
+		{
+			addr:   net.ParseIP("192.168.1.1").To4(),
+			family: 4,
+		},
+		{
+			addr:   net.ParseIP("::ffff:192.168.1.1"),
+			family: 4,
+		},
+		{
+			addr:   net.ParseIP("2001:dead::1"),
+			family: 6,
+		},
+		{
+			addr:   net.ParseIP("::"),
+			family: 6,
+		},
+		{
+			addr:   net.IP{1, 2, 3},
+			family: 0,
+		},
+		{
+			addr:   nil,
+			family: 0,
+		},
+	}
+	for i, c := range testData {
+		if f := Family(c.addr); f != c.family {
+			t.Fatalf("case %d: result family %d is different from expected %d", i, f, c.family)
+		}
+		if IsIPv4(c.addr) != (c.family == 4) {
+			t.Fatalf("case %d: IsIPv4 returned %v", i, IsIPv4(c.addr))
+		}
+		if IsIPv6(c.addr) != (c.family == 6) {
+			t.Fatalf("case %d: IsIPv6 returned %v", i, IsIPv6(c.addr))
+		}
+	}
+}
+
+type testNetipConvertCase struct {
+	ip         net.IP
+	expected   string
+	is4        bool
+	shouldFail bool
+}
+
+func TestNetipConvertion(t *testing.T) {
+	testData := []testNetipConvertCase{
+		{
+			ip:       net.ParseIP("192.168.1.1"),
+			expected: "192.168.1.1",
+			is4:      true,
+		},
+		{
+			ip:       net.ParseIP("192.168.1.1").To4(),
+			expected: "192.168.1.1",
+			is4:      true,
+		},
+		{
+			ip:       net.ParseIP("2001:dead::1"),
+			expected: "2001:dead::1",
+		},
+		{
+			ip:         net.IP{1, 2, 3},
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testNetipConvertCase) error {
+		addr, ok := ToNetip(c.ip)
+		if !ok {
+			return fmt.Errorf("failed to convert %v", c.ip)
+		}
+		if addr.String() != c.expected || addr.Is4() != c.is4 {
+			return fmt.Errorf("result %v is different from expected %v", addr, c.expected)
+		}
+		ip := ToNetIP(addr)
+		if !ip.Equal(c.ip) {
+			return fmt.Errorf("converted back ip %v is different from original %v", ip, c.ip)
+		}
+		if c.is4 && len(ip) != net.IPv4len {
+			return fmt.Errorf("converted back ip %v is not 4-byte form", ip)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if ToNetIP(netip.Addr{}) != nil {
+		t.Fatal("converting invalid netip.Addr should return nil")
+	}
+	if ip := ToNetIP(netip.MustParseAddr("fe80::1%eth0")); !ip.Equal(net.ParseIP("fe80::1")) {
+		t.Fatalf("zoned address converted to %v", ip)
+	}
+}
+
+type testParseAddrToBigCase struct {
+	addrStr    string
+	expectedN  string
+	bits       int
+	shouldFail bool
+}
+
+func TestParseAddrToBig(t *testing.T) {
+	testData := []testParseAddrToBigCase{
+		{
+			addrStr:   "1.2.3.4",
+			expectedN: "16909060",
+			bits:      32,
+		},
+		{
+			addrStr:   "::ffff:1.2.3.4",
+			expectedN: "16909060",
+			bits:      32,
+		},
+		{
+			addrStr:   "::1:0",
+			expectedN: "65536",
+			bits:      128,
+		},
+		{
+			addrStr:   "FFFF:FFFF:FFFF:FFFF:FFFF:FFFF:FFFF:FFFF",
+			expectedN: MaxIPv6AddrStr,
+			bits:      128,
+		},
+		{
+			addrStr:    "1.2.3",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testParseAddrToBigCase) error {
+		n, bits, err := ParseAddrToBig(c.addrStr)
+		if err != nil {
+			return err
+		}
+		if n.String() != c.expectedN || bits != c.bits {
+			return fmt.Errorf("result %v/%d is different from expected %v/%d", n, bits, c.expectedN, c.bits)
+		}
+		addr, err := BigtoAddr(n, bits == 32)
+		if err != nil {
+			return err
+		}
+		if !addr.Equal(net.ParseIP(c.addrStr)) {
+			return fmt.Errorf("converted back addr %v is different from original %v", addr, c.addrStr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testGenConnectionURLCase struct {
+	scheme       string
+	host         string
+	port         int
+	path         string
+	expectedAddr string
+	shouldFail   bool
+}
+
+func TestGenConnectionURL(t *testing.T) {
+	testData := []testGenConnectionURLCase{
+		{
+			scheme:       "http",
+			host:         "192.168.1.1",
+			port:         8043,
+			expectedAddr: "http://192.168.1.1:8043",
+		},
+		{
+			scheme:       "https",
+			host:         "2001:dead::1",
+			port:         443,
+			path:         "api/v1",
+			expectedAddr: "https://[2001:dead::1]:443/api/v1",
+		},
+		{
+			scheme:       "http",
+			host:         "fe80::1%eth0",
+			port:         80,
+			expectedAddr: "http://[fe80::1%25eth0]:80",
+		},
+		{
+			scheme:       "grpc",
+			host:         "example.com",
+			port:         50051,
+			path:         "/svc",
+			expectedAddr: "grpc://example.com:50051/svc",
+		},
+		{
+			scheme:     "http",
+			host:       "example.com",
+			port:       70000,
+			shouldFail: true,
+		},
+		{
+			scheme:     "http",
+			port:       80,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testGenConnectionURLCase) error {
+		rstr, err := GenConnectionURL(c.scheme, c.host, c.port, c.path)
+		if err != nil {
+			return err
+		}
+		if rstr != c.expectedAddr {
+			return fmt.Errorf("result %v is different from expected %v", rstr, c.expectedAddr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestIncIPv4(t *testing.T) {
+	testData := []testIncCase{
+		{
+			addrStr:      "1.1.1.1",
+			step:         1,
+			expectedAddr: "1.1.1.2",
+		},
+		{
+			addrStr:      "192.168.10.255",
+			step:         10,
+			expectedAddr: "192.168.11.9",
+		},
+		{
+			addrStr:      "1.1.2.0",
+			step:         -1,
+			expectedAddr: "1.1.1.255",
+		},
+		{
+			addrStr:      "0.0.0.0",
+			step:         MaxIPv4AddrN,
+			expectedAddr: "255.255.255.255",
+		},
+		{
+			addrStr:    "0.0.0.1",
+			step:       -10,
+			shouldFail: true,
+		},
+		{
+			addrStr:    "255.255.255.255",
+			step:       1,
+			shouldFail: true,
+		},
+		{
+			addrStr:    "1.1.1.1",
+			step:       1 << 62,
+			shouldFail: true,
+		},
+		{
+			addrStr:    "::4",
+			step:       1,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testIncCase) error {
+		raddr, err := IncIPv4(netip.MustParseAddr(c.addrStr), c.step)
+		if err != nil {
+			return err
+		}
+		if raddr.String() != c.expectedAddr {
+			return fmt.Errorf("result addr %v is different from expected %v", raddr, c.expectedAddr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func BenchmarkIncIPv4(b *testing.B) {
+	addr := netip.MustParseAddr("10.0.0.0")
+	for i := 0; i < b.N; i++ {
+		if _, err := IncIPv4(addr, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIncAddrIPv4(b *testing.B) {
+	addr := net.ParseIP("10.0.0.0")
+	step := big.NewInt(1)
+	for i := 0; i < b.N; i++ {
+		if _, err := IncAddr(addr, step); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type testIPNetPrefixCase struct {
+	prefixStr  string
+	ipnet      *net.IPNet
+	expected   string
+	shouldFail bool
+}
+
+func TestIPNetPrefixConvertion(t *testing.T) {
+	testData := []testIPNetPrefixCase{
+		{
+			prefixStr: "192.168.1.0/24",
+			expected:  "192.168.1.0/24",
+		},
+		{
+			prefixStr: "192.168.1.100/24",
+			expected:  "192.168.1.0/24",
+		},
+		{
+			prefixStr: "2001:dead:beef::1/48",
+			expected:  "2001:dead:beef::/48",
+		},
+		{
+			prefixStr: "0.0.0.0/0",
+			expected:  "0.0.0.0/0",
+		},
+		{
+			prefixStr: "::/0",
+			expected:  "::/0",
+		},
+		{
+			ipnet: &net.IPNet{
+				IP:   net.ParseIP("10.1.1.1"),
+				Mask: net.CIDRMask(104, 128),
+			},
+			expected: "10.0.0.0/8",
+		},
+		{
+			ipnet: &net.IPNet{
+				IP:   net.ParseIP("10.1.1.1"),
+				Mask: net.IPv4Mask(255, 0, 255, 0),
+			},
+			shouldFail: true,
+		},
+		{
+			ipnet: &net.IPNet{
+				IP:   net.ParseIP("2001:dead::1"),
+				Mask: net.CIDRMask(24, 32),
+			},
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testIPNetPrefixCase) error {
+		ipnet := c.ipnet
+		if ipnet == nil {
+			_, ipnet, _ = net.ParseCIDR(c.prefixStr)
+			if n := PrefixToIPNet(netip.MustParsePrefix(c.prefixStr)); n.String() != ipnet.String() || len(n.IP) != len(ipnet.IP) {
+				return fmt.Errorf("PrefixToIPNet result %v is different from expected %v", n, ipnet)
+			}
+		}
+		prefix, err := IPNetToPrefix(ipnet)
+		if err != nil {
+			return err
+		}
+		if prefix.String() != c.expected {
+			return fmt.Errorf("IPNetToPrefix result %v is different from expected %v", prefix, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if PrefixToIPNet(netip.Prefix{}) != nil {
+		t.Fatal("converting invalid prefix should return nil")
+	}
+}
+
+func TestIncAddrInto(t *testing.T) {
+	testData := []testIncCase{
+		{
+			addrStr:      "1.1.1.255",
+			step:         1,
+			expectedAddr: "1.1.2.0",
+		},
+		{
+			addrStr:      "::3:4",
+			step:         -5,
+			expectedAddr: "::2:ffff",
+		},
+		{
+			addrStr:    "255.255.255.255",
+			step:       1,
+			shouldFail: true,
+		},
+		{
+			addrStr:    "::",
+			step:       -1,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testIncCase) error {
+		addr := net.ParseIP(c.addrStr)
+		dst := make(net.IP, 16)
+		if IsIPv4(addr) {
+			dst = dst[:4]
+		}
+		if err := IncAddrInto(dst, addr, big.NewInt(c.step)); err != nil {
+			return err
+		}
+		if !dst.Equal(net.ParseIP(c.expectedAddr)) {
+			return fmt.Errorf("result addr %v is different from expected %v", dst, c.expectedAddr)
+		}
+		//in place
+		if err := IncAddrInto(dst, dst, big.NewInt(-c.step)); err != nil {
+			return err
+		}
+		if !dst.Equal(addr) {
+			return fmt.Errorf("result addr %v is different from expected %v", dst, addr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if err := IncAddrInto(make(net.IP, 8), net.ParseIP("1.1.1.1"), big.NewInt(1)); err == nil {
+		t.Fatal("wrong dst length should fail")
+	}
+	//in place on a 16-byte IPv4 address
+	ip := net.ParseIP("10.0.0.1")
+	if err := IncAddrInto(ip, ip, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(ip) != net.IPv6len || !ip.Equal(net.ParseIP("10.0.0.2")) {
+		t.Fatalf("in place result %v is different from expected 10.0.0.2", ip)
+	}
+	mac, _ := net.ParseMAC("00:11:22:33:44:ff")
+	dst := make(net.HardwareAddr, 6)
+	if err := IncMACAddrInto(dst, mac, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if dst.String() != "00:11:22:33:45:00" {
+		t.Fatalf("result mac %v is different from expected 00:11:22:33:45:00", dst)
+	}
+	if err := IncMACAddrInto(dst[:4], mac, big.NewInt(1)); err == nil {
+		t.Fatal("wrong dst length should fail")
+	}
+	mac, _ = net.ParseMAC("ff:ff:ff:ff:ff:ff")
+	if err := IncMACAddrInto(dst, mac, big.NewInt(1)); err == nil {
+		t.Fatal("overflow should fail")
+	}
+	eui64 := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77}
+	if err := IncMACAddrInto(dst, eui64, big.NewInt(1)); err == nil {
+		t.Fatal("8-byte EUI-64 address should fail")
+	}
+}
+
+type testParseStepCase struct {
+	stepStr    string
+	expected   string
+	shouldFail bool
+}
+
+func TestParseStep(t *testing.T) {
+	testData := []testParseStepCase{
+		{
+			stepStr:  "256",
+			expected: "256",
+		},
+		{
+			stepStr:  "+256",
+			expected: "256",
+		},
+		{
+			stepStr:  "-10",
+			expected: "-10",
+		},
+		{
+			stepStr:  "010",
+			expected: "10",
+		},
+		{
+			stepStr:  "0x100",
+			expected: "256",
+		},
+		{
+			stepStr:  "-0X10",
+			expected: "-16",
+		},
+		{
+			stepStr:  "0x10000000000000000000000000000000",
+			expected: "21267647932558653966460912964485513216",
+		},
+		{
+			stepStr:  "340282366920938463463374607431768211455",
+			expected: MaxIPv6AddrStr,
+		},
+		{
+			stepStr:    "--1",
+			shouldFail: true,
+		},
+		{
+			stepStr:    "0x",
+			shouldFail: true,
+		},
+		{
+			stepStr:    "0x-5",
+			shouldFail: true,
+		},
+		{
+			stepStr:    "1.5",
+			shouldFail: true,
+		},
+		{
+			stepStr:    "",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testParseStepCase) error {
+		r, err := ParseStep(c.stepStr)
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestGetLLAsFromMacs(t *testing.T) {
+	mac1, _ := net.ParseMAC("4a:08:5d:b5:91:ed")
+	mac2, _ := net.ParseMAC("00:11:22:33:44:55")
+	llas, err := GetLLAsFromMacs([]net.HardwareAddr{mac1, mac2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[fe80::4808:5dff:feb5:91ed fe80::211:22ff:fe33:4455]"
+	if fmt.Sprint(llas) != expected {
+		t.Fatalf("result %v is different from expected %v", llas, expected)
+	}
+	_, err = GetLLAsFromMacs([]net.HardwareAddr{mac1, mac2, {1, 2}})
+	if err == nil || !strings.Contains(err.Error(), "index 2") {
+		t.Fatalf("expect error with index 2, got %v", err)
+	}
+}
+
+type testIncAddrWrapRangeCase struct {
+	addrStr      string
+	step         int64
+	low, high    string
+	expectedAddr string
+	shouldFail   bool
+}
+
+func TestIncAddrWrapRange(t *testing.T) {
+	testData := []testIncAddrWrapRangeCase{
+		{
+			addrStr:      "10.0.0.5",
+			step:         3,
+			low:          "10.0.0.1",
+			high:         "10.0.0.10",
+			expectedAddr: "10.0.0.8",
+		},
+		{
+			addrStr:      "10.0.0.10",
+			step:         1,
+			low:          "10.0.0.1",
+			high:         "10.0.0.10",
+			expectedAddr: "10.0.0.1",
+		},
+		{
+			addrStr:      "10.0.0.8",
+			step:         5,
+			low:          "10.0.0.1",
+			high:         "10.0.0.10",
+			expectedAddr: "10.0.0.3",
+		},
+		{
+			addrStr:      "10.0.0.1",
+			step:         -1,
+			low:          "10.0.0.1",
+			high:         "10.0.0.10",
+			expectedAddr: "10.0.0.10",
+		},
+		{
+			addrStr:      "10.0.0.1",
+			step:         5,
+			low:          "10.0.0.1",
+			high:         "10.0.0.1",
+			expectedAddr: "10.0.0.1",
+		},
+		{
+			addrStr:      "2001:dead::ffff",
+			step:         2,
+			low:          "2001:dead::fff0",
+			high:         "2001:dead::ffff",
+			expectedAddr: "2001:dead::fff1",
+		},
+		{
+			addrStr:    "10.0.0.11",
+			step:       1,
+			low:        "10.0.0.1",
+			high:       "10.0.0.10",
+			shouldFail: true,
+		},
+		{
+			addrStr:    "10.0.0.5",
+			step:       1,
+			low:        "10.0.0.10",
+			high:       "10.0.0.1",
+			shouldFail: true,
+		},
+		{
+			addrStr:    "10.0.0.5",
+			step:       1,
+			low:        "::",
+			high:       "10.0.0.10",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testIncAddrWrapRangeCase) error {
+		r, err := IncAddrWrapRange(net.ParseIP(c.addrStr), big.NewInt(c.step), net.ParseIP(c.low), net.ParseIP(c.high))
+		if err != nil {
+			return err
+		}
+		if !r.Equal(net.ParseIP(c.expectedAddr)) {
+			return fmt.Errorf("result addr %v is different from expected %v", r, c.expectedAddr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestSLAACPrefixPlan(t *testing.T) {
+	mac1, _ := net.ParseMAC("4a:08:5d:b5:91:ed")
+	mac2, _ := net.ParseMAC("00:11:22:33:44:55")
+	plan, err := SLAACPrefixPlan(netip.MustParsePrefix("2001:dead:beef:1::/64"), []net.HardwareAddr{mac1, mac2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"4a:08:5d:b5:91:ed": "2001:dead:beef:1:4808:5dff:feb5:91ed",
+		"00:11:22:33:44:55": "2001:dead:beef:1:211:22ff:fe33:4455",
+	}
+	if len(plan) != len(expected) {
+		t.Fatalf("result %v is different from expected %v", plan, expected)
+	}
+	for mac, addr := range expected {
+		if plan[mac].String() != addr {
+			t.Fatalf("result %v is different from expected %v", plan, expected)
+		}
+	}
+	for _, p := range []string{"2001:dead:beef::/56", "10.0.0.0/24"} {
+		if _, err := SLAACPrefixPlan(netip.MustParsePrefix(p), []net.HardwareAddr{mac1}); err == nil {
+			t.Fatalf("prefix %v should fail", p)
+		}
+	}
+	if _, err := SLAACPrefixPlan(netip.MustParsePrefix("2001:dead:beef:1::/64"), []net.HardwareAddr{mac1, {1, 2, 3}}); err == nil {
+		t.Fatal("invalid MAC address should fail")
+	}
+}
+
+type testIncAddrWatchBoundaryCase struct {
+	addrStr         string
+	step            int64
+	boundaryBits    int
+	expectedAddr    string
+	expectedCrossed bool
+	shouldFail      bool
+}
+
+func TestIncAddrWatchBoundary(t *testing.T) {
+	testData := []testIncAddrWatchBoundaryCase{
+		{
+			addrStr:      "192.168.1.250",
+			step:         5,
+			boundaryBits: 24,
+			expectedAddr: "192.168.1.255",
+		},
+		{
+			addrStr:         "192.168.1.250",
+			step:            6,
+			boundaryBits:    24,
+			expectedAddr:    "192.168.2.0",
+			expectedCrossed: true,
+		},
+		{
+			addrStr:         "192.168.2.0",
+			step:            -1,
+			boundaryBits:    24,
+			expectedAddr:    "192.168.1.255",
+			expectedCrossed: true,
+		},
+		{
+			addrStr:      "192.168.1.250",
+			step:         6,
+			boundaryBits: 16,
+			expectedAddr: "192.168.2.0",
+		},
+		{
+			addrStr:      "192.168.1.250",
+			step:         1000,
+			boundaryBits: 0,
+			expectedAddr: "192.168.5.226",
+		},
+		{
+			addrStr:         "2001:dead::ffff",
+			step:            1,
+			boundaryBits:    112,
+			expectedAddr:    "2001:dead::1:0",
+			expectedCrossed: true,
+		},
+		{
+			addrStr:      "192.168.1.1",
+			step:         1,
+			boundaryBits: 33,
+			shouldFail:   true,
+		},
+		{
+			addrStr:      "255.255.255.255",
+			step:         1,
+			boundaryBits: 24,
+			shouldFail:   true,
+		},
+		//nil net.IP
+		{
+			addrStr:      "not-an-address",
+			step:         1,
+			boundaryBits: 24,
+			shouldFail:   true,
+		},
+	}
+	runTest := func(c testIncAddrWatchBoundaryCase) error {
+		r, crossed, err := IncAddrWatchBoundary(net.ParseIP(c.addrStr), big.NewInt(c.step), c.boundaryBits)
+		if err != nil {
+			return err
+		}
+		if !r.Equal(net.ParseIP(c.expectedAddr)) {
+			return fmt.Errorf("result addr %v is different from expected %v", r, c.expectedAddr)
+		}
+		if crossed != c.expectedCrossed {
+			return fmt.Errorf("crossed %v is different from expected %v", crossed, c.expectedCrossed)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if _, _, err := IncAddrWatchBoundary(net.IP{1, 2, 3}, big.NewInt(1), 0); err == nil {
+		t.Fatal("malformed address should fail")
+	}
+}
+
+type testIncUntilCase struct {
+	start, stop string
+	step        int64
+	expected    string
+	shouldFail  bool
+}
+
+func TestIncUntil(t *testing.T) {
+	testData := []testIncUntilCase{
+		{start: "10.0.0.1", stop: "10.0.0.4", step: 1, expected: "[10.0.0.1 10.0.0.2 10.0.0.3]"},
+		{start: "10.0.0.1", stop: "10.0.0.5", step: 2, expected: "[10.0.0.1 10.0.0.3]"},
+		{start: "10.0.0.1", stop: "10.0.0.6", step: 2, expected: "[10.0.0.1 10.0.0.3 10.0.0.5]"},
+		{start: "10.0.0.1", stop: "10.0.0.1", step: 1, expected: "[]"},
+		{start: "255.255.255.254", stop: "255.255.255.255", step: 5, expected: "[255.255.255.254]"},
+		{start: "2001:dead::ffff", stop: "2001:dead::1:1", step: 1, expected: "[2001:dead::ffff 2001:dead::1:0]"},
+		{start: "10.0.0.5", stop: "10.0.0.1", step: 1, shouldFail: true},
+		{start: "10.0.0.1", stop: "10.0.0.5", step: 0, shouldFail: true},
+		{start: "10.0.0.1", stop: "::a00:5", step: 1, shouldFail: true},
+	}
+	runTest := func(c testIncUntilCase) error {
+		r, err := IncUntil(net.ParseIP(c.start), net.ParseIP(c.stop), big.NewInt(c.step))
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(r) != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testGenAddrWithStructuredHostCase struct {
+	prefix       string
+	fields       []uint
+	widths       []int
+	expectedAddr string
+	shouldFail   bool
+}
+
+func TestGenAddrWithStructuredHost(t *testing.T) {
+	testData := []testGenAddrWithStructuredHostCase{
+		//slot 3, port 5
+		{
+			prefix:       "10.0.0.0/16",
+			fields:       []uint{3, 5},
+			widths:       []int{4, 8},
+			expectedAddr: "10.0.3.5",
+		},
+		{
+			prefix:       "10.0.0.0/16",
+			fields:       []uint{15, 255},
+			widths:       []int{8, 8},
+			expectedAddr: "10.0.15.255",
+		},
+		{
+			prefix:       "2001:dead::/64",
+			fields:       []uint{1, 0xffffffffffffffff >> 8},
+			widths:       []int{8, 56},
+			expectedAddr: "2001:dead::1ff:ffff:ffff:ffff",
+		},
+		{
+			prefix:       "::ffff:10.0.0.0/112",
+			fields:       []uint{3, 5},
+			widths:       []int{4, 8},
+			expectedAddr: "::ffff:10.0.3.5",
+		},
+		{
+			prefix:       "10.0.0.0/24",
+			fields:       []uint{},
+			widths:       []int{},
+			expectedAddr: "10.0.0.0",
+		},
+		{
+			prefix:     "10.0.0.0/16",
+			fields:     []uint{16, 5},
+			widths:     []int{4, 8},
+			shouldFail: true,
+		},
+		{
+			prefix:     "10.0.0.0/24",
+			fields:     []uint{3, 5},
+			widths:     []int{4, 8},
+			shouldFail: true,
+		},
+		{
+			prefix:     "10.0.0.0/16",
+			fields:     []uint{3, 5},
+			widths:     []int{4, 0},
+			shouldFail: true,
+		},
+		{
+			prefix:     "10.0.0.0/16",
+			fields:     []uint{3},
+			widths:     []int{4, 8},
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testGenAddrWithStructuredHostCase) error {
+		r, err := GenAddrWithStructuredHost(netip.MustParsePrefix(c.prefix), c.fields, c.widths)
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expectedAddr {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedAddr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestSubnetRouterAnycast(t *testing.T) {
+	prefix := netip.MustParsePrefix("2001:dead:beef:1:2:3:4:5/64")
+	r, err := SubnetRouterAnycast(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "2001:dead:beef:1::" || !IsHostZero(prefix, r) {
+		t.Fatalf("result %v is not the network address of %v", r, prefix)
+	}
+	if _, err := SubnetRouterAnycast(netip.MustParsePrefix("10.0.0.0/24")); err == nil {
+		t.Fatal("IPv4 prefix should fail")
+	}
+}
+
+func TestIPsToAddrs(t *testing.T) {
+	ips := []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("::ffff:10.0.0.2"), net.ParseIP("2001:dead::1")}
+	addrs, err := IPsToAddrs(ips)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(addrs) != "[10.0.0.1 10.0.0.2 2001:dead::1]" || !addrs[1].Is4() {
+		t.Fatalf("unexpected result %v", addrs)
+	}
+	r := AddrsToIPs(addrs)
+	if len(r) != 3 || len(r[0]) != 4 || len(r[1]) != 4 || len(r[2]) != 16 {
+		t.Fatalf("unexpected result %v", r)
+	}
+	for i := range r {
+		if !r[i].Equal(ips[i]) {
+			t.Fatalf("round trip result %v is different from %v", r[i], ips[i])
+		}
+	}
+	_, err = IPsToAddrs([]net.IP{net.ParseIP("10.0.0.1"), {1, 2, 3}})
+	if err == nil || !strings.Contains(err.Error(), "index 1") {
+		t.Fatalf("expect error with index 1, got %v", err)
+	}
+	if r := AddrsToIPs([]netip.Addr{{}}); r[0] != nil {
+		t.Fatalf("result %v of invalid address is not nil", r[0])
+	}
+}
+
+func TestGenStableAddr(t *testing.T) {
+	prefix := netip.MustParsePrefix("2001:dead:beef:1::/64")
+	addr, err := GenStableAddr(prefix, [8]byte{0, 0, 0, 0, 0, 0, 0x12, 0x34})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.String() != "2001:dead:beef:1::1234" {
+		t.Fatalf("result %v is different from expected 2001:dead:beef:1::1234", addr)
+	}
+	if _, err := GenStableAddr(netip.MustParsePrefix("2001:dead:beef::/48"), [8]byte{}); err == nil {
+		t.Fatal("non /64 prefix should fail")
+	}
+	iid1 := RandomIID(rand.New(rand.NewSource(1)))
+	iid2 := RandomIID(rand.New(rand.NewSource(1)))
+	if iid1 != iid2 {
+		t.Fatalf("same seed generates different IID %v and %v", iid1, iid2)
+	}
+	for i := 0; i < 100; i++ {
+		if iid := RandomIID(nil); iid[0]&0x2 != 0 {
+			t.Fatalf("universal/local bit of %v is set", iid)
+		}
+	}
+	addr, err = GenStableAddr(prefix, iid1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !prefix.Contains(addr) {
+		t.Fatalf("%v is not in %v", addr, prefix)
+	}
+}
+
+func TestAddrsWithSuffix(t *testing.T) {
+	type testAddrsWithSuffixCase struct {
+		prefixes   []string
+		expected   []string
+		shouldFail bool
+	}
+	iid := [8]byte{0, 0, 0, 0, 0, 0, 0, 0x53}
+	testData := []testAddrsWithSuffixCase{
+		{
+			prefixes: []string{"2001:db8:1::/64", "2001:db8:2::/64", "2001:db8:1:ffff::/64"},
+			expected: []string{"2001:db8:1::53", "2001:db8:2::53", "2001:db8:1:ffff::53"},
+		},
+		{
+			prefixes: []string{},
+			expected: []string{},
+		},
+		{
+			prefixes:   []string{"2001:db8:1::/64", "2001:db8:2::/56"},
+			shouldFail: true,
+		},
+		{
+			prefixes:   []string{"10.0.0.0/24"},
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testAddrsWithSuffixCase) error {
+		prefixes := make([]netip.Prefix, len(c.prefixes))
+		for i, p := range c.prefixes {
+			prefixes[i] = netip.MustParsePrefix(p)
+		}
+		r, err := AddrsWithSuffix(prefixes, iid)
+		if err != nil {
+			return err
+		}
+		if len(r) != len(c.expected) {
+			return fmt.Errorf("got %d addresses, expect %d", len(r), len(c.expected))
+		}
+		for i, addr := range r {
+			if addr.String() != c.expected[i] {
+				return fmt.Errorf("address %d is %v, expect %v", i, addr, c.expected[i])
+			}
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
 }
@@ -0,0 +1,223 @@
+// Copyright 2020 Hu Jun. All rights reserved.
+// This project is licensed under the terms of the MIT license.
+// license that can be found in the LICENSE file.
+
+package myaddr
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"sync"
+)
+
+// ErrPoolExhausted is returned when there is no more address/prefix left in the pool
+var ErrPoolExhausted = errors.New("pool exhausted")
+
+// MaxUsableHosts is the max number of addresses UsableHosts returns,
+// to avoid running out of memory on a big prefix; it must be positive
+var MaxUsableHosts = 65536
+
+// AddrPool hands out addresses of a prefix in ascending order,
+// it is not safe for concurrent use
+type AddrPool struct {
+	prefix netip.Prefix
+	//offset of next address to the network address
+	next *big.Int
+	//offset of the address after the last address could be returned
+	end *big.Int
+	//only address for which filter returns true is returned, if filter is not nil
+	filter func(netip.Addr) bool
+}
+
+// NewAddrPool return an AddrPool that returns every address of prefix,
+// starting from the network address
+func NewAddrPool(prefix netip.Prefix) *AddrPool {
+	prefix = prefix.Masked()
+	r := &AddrPool{
+		prefix: prefix,
+		next:   big.NewInt(0),
+		end:    big.NewInt(0),
+	}
+	if prefix.IsValid() {
+		r.end = hostCount(prefix)
+	}
+	return r
+}
+
+// NewAddrPoolUsable return an AddrPool that returns only usable host addresses of prefix:
+// for IPv4 prefix with prefix length <= 30, network and broadcast address are skipped;
+// for IPv4 /31, /32 and all IPv6 prefixes, every address is returned
+func NewAddrPoolUsable(prefix netip.Prefix) *AddrPool {
+	r := NewAddrPool(prefix)
+	if prefix.Addr().Is4() && prefix.Bits() <= 30 {
+		r.next.SetInt64(1)
+		r.end.Sub(r.end, big.NewInt(1))
+	}
+	return r
+}
+
+// SetFilter set a filter for the pool, after which Next only returns address for which
+// filter returns true, e.g. SetFilter(func(a netip.Addr) bool { return AddrScope(a) == ScopeGlobal }).
+// Next checks addresses one by one, so its cost is proportional to the number of consecutive
+// filtered addresses, it could take very long if a large part of a big prefix is filtered
+func (pool *AddrPool) SetFilter(filter func(netip.Addr) bool) {
+	pool.filter = filter
+}
+
+// Next return next address in the pool, return ErrPoolExhausted if there is no more
+func (pool *AddrPool) Next() (netip.Addr, error) {
+	if !pool.prefix.IsValid() {
+		return netip.Addr{}, fmt.Errorf("invalid prefix %v", pool.prefix)
+	}
+	for pool.next.Cmp(pool.end) < 0 {
+		n := new(big.Int).Add(netipToBig(pool.prefix.Addr()), pool.next)
+		r, err := bigToNetip(n, pool.prefix.Addr().BitLen())
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		pool.next.Add(pool.next, big.NewInt(1))
+		if pool.filter == nil || pool.filter(r) {
+			return r, nil
+		}
+	}
+	return netip.Addr{}, ErrPoolExhausted
+}
+
+// UsableHosts return all usable host addresses of prefix in ascending order, see NewAddrPoolUsable;
+// return error if there are more than MaxUsableHosts of them
+func UsableHosts(prefix netip.Prefix) ([]netip.Addr, error) {
+	if !prefix.IsValid() {
+		return nil, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	if MaxUsableHosts <= 0 {
+		return nil, fmt.Errorf("MaxUsableHosts %d is not positive", MaxUsableHosts)
+	}
+	pool := NewAddrPoolUsable(prefix)
+	count := new(big.Int).Sub(pool.end, pool.next)
+	if count.Cmp(big.NewInt(int64(MaxUsableHosts))) > 0 {
+		return nil, fmt.Errorf("%v has %v usable hosts, more than max %d", prefix, count, MaxUsableHosts)
+	}
+	r := make([]netip.Addr, 0, count.Int64())
+	for {
+		addr, err := pool.Next()
+		if errors.Is(err, ErrPoolExhausted) {
+			return r, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, addr)
+	}
+}
+
+// MultiPool hands out addresses from multiple prefixes in round-robin order,
+// each prefix is an AddrPool; it is safe for concurrent use
+type MultiPool struct {
+	lock  sync.Mutex
+	pools []*AddrPool
+	//index of the pool to try first in next call of Next
+	next int
+}
+
+// NewMultiPool return a MultiPool that returns every address of prefixes, see NewAddrPool
+func NewMultiPool(prefixes []netip.Prefix) (*MultiPool, error) {
+	r := &MultiPool{
+		pools: make([]*AddrPool, len(prefixes)),
+	}
+	for i, prefix := range prefixes {
+		if !prefix.IsValid() {
+			return nil, fmt.Errorf("invalid prefix %v at index %d", prefix, i)
+		}
+		r.pools[i] = NewAddrPool(prefix)
+	}
+	return r, nil
+}
+
+// Next return next address from the pools in round-robin order, exhausted pools are skipped;
+// return ErrPoolExhausted if all pools are exhausted
+func (mp *MultiPool) Next() (netip.Addr, error) {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+	for i := 0; i < len(mp.pools); i++ {
+		idx := (mp.next + i) % len(mp.pools)
+		addr, err := mp.pools[idx].Next()
+		if errors.Is(err, ErrPoolExhausted) {
+			continue
+		}
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		mp.next = (idx + 1) % len(mp.pools)
+		return addr, nil
+	}
+	return netip.Addr{}, ErrPoolExhausted
+}
+
+// PrefixPool hands out sub-prefixes of a parent prefix,
+// it is not safe for concurrent use
+type PrefixPool struct {
+	parent    netip.Prefix
+	allocated []netip.Prefix
+}
+
+// NewPrefixPool return a PrefixPool that hands out sub-prefixes of parent
+func NewPrefixPool(parent netip.Prefix) *PrefixPool {
+	return &PrefixPool{
+		parent:    parent.Masked(),
+		allocated: []netip.Prefix{},
+	}
+}
+
+// Allocate return the lowest free sub-prefix with prefix length bits,
+// return ErrPoolExhausted if there is no such sub-prefix left
+func (pool *PrefixPool) Allocate(bits int) (netip.Prefix, error) {
+	if !pool.parent.IsValid() {
+		return netip.Prefix{}, fmt.Errorf("invalid prefix %v", pool.parent)
+	}
+	if bits < pool.parent.Bits() || bits > pool.parent.Addr().BitLen() {
+		return netip.Prefix{}, fmt.Errorf("invalid prefix length %d for sub-prefix of %v", bits, pool.parent)
+	}
+	free, err := FindGaps(pool.allocated, pool.parent)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	//free prefixes are the largest aligned blocks in ascending order,
+	//so first one that is big enough has the lowest aligned block
+	for _, f := range free {
+		if f.Bits() <= bits {
+			r := netip.PrefixFrom(f.Addr(), bits)
+			pool.allocated = append(pool.allocated, r)
+			return r, nil
+		}
+	}
+	return netip.Prefix{}, ErrPoolExhausted
+}
+
+// Release return prefix to the pool, prefix must be one returned by Allocate
+func (pool *PrefixPool) Release(prefix netip.Prefix) error {
+	for i, p := range pool.allocated {
+		if p == prefix {
+			pool.allocated = append(pool.allocated[:i], pool.allocated[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%v is not allocated from the pool", prefix)
+}
+
+// PackPrefixes allocate a sub-prefix of parent for each of prefix length in sizes, in order,
+// each one is the lowest free aligned block like PrefixPool.Allocate;
+// return error wrapping ErrPoolExhausted if they don't fit into parent
+func PackPrefixes(parent netip.Prefix, sizes []int) ([]netip.Prefix, error) {
+	pool := NewPrefixPool(parent)
+	r := make([]netip.Prefix, 0, len(sizes))
+	for i, bits := range sizes {
+		p, err := pool.Allocate(bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate /%d at index %d, %w", bits, i, err)
+		}
+		r = append(r, p)
+	}
+	return r, nil
+}
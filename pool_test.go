@@ -0,0 +1,354 @@
+// myaddr_test
+package myaddr
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync"
+	"testing"
+)
+
+type testAddrPoolCase struct {
+	prefix        string
+	usable        bool
+	expectedCount int
+	expectedFirst string
+	expectedLast  string
+}
+
+func TestAddrPool(t *testing.T) {
+	testData := []testAddrPoolCase{
+		{
+			prefix:        "192.168.1.0/29",
+			expectedCount: 8,
+			expectedFirst: "192.168.1.0",
+			expectedLast:  "192.168.1.7",
+		},
+		{
+			prefix:        "192.168.1.0/29",
+			usable:        true,
+			expectedCount: 6,
+			expectedFirst: "192.168.1.1",
+			expectedLast:  "192.168.1.6",
+		},
+		{
+			prefix:        "192.168.1.0/30",
+			usable:        true,
+			expectedCount: 2,
+			expectedFirst: "192.168.1.1",
+			expectedLast:  "192.168.1.2",
+		},
+		{
+			prefix:        "192.168.1.0/31",
+			usable:        true,
+			expectedCount: 2,
+			expectedFirst: "192.168.1.0",
+			expectedLast:  "192.168.1.1",
+		},
+		{
+			prefix:        "192.168.1.1/32",
+			usable:        true,
+			expectedCount: 1,
+			expectedFirst: "192.168.1.1",
+			expectedLast:  "192.168.1.1",
+		},
+		{
+			prefix:        "2001:dead::/126",
+			usable:        true,
+			expectedCount: 4,
+			expectedFirst: "2001:dead::",
+			expectedLast:  "2001:dead::3",
+		},
+	}
+	runTest := func(c testAddrPoolCase) error {
+		var pool *AddrPool
+		if c.usable {
+			pool = NewAddrPoolUsable(netip.MustParsePrefix(c.prefix))
+		} else {
+			pool = NewAddrPool(netip.MustParsePrefix(c.prefix))
+		}
+		addrs := []netip.Addr{}
+		for {
+			addr, err := pool.Next()
+			if err != nil {
+				if errors.Is(err, ErrPoolExhausted) {
+					break
+				}
+				return err
+			}
+			addrs = append(addrs, addr)
+		}
+		if len(addrs) != c.expectedCount {
+			return fmt.Errorf("got %d addresses from %v, expected %d", len(addrs), c.prefix, c.expectedCount)
+		}
+		if addrs[0].String() != c.expectedFirst || addrs[len(addrs)-1].String() != c.expectedLast {
+			return fmt.Errorf("got addresses %v-%v from %v, expected %v-%v", addrs[0], addrs[len(addrs)-1], c.prefix, c.expectedFirst, c.expectedLast)
+		}
+		return nil
+	}
+	for _, c := range testData {
+		if err := runTest(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := NewAddrPool(netip.Prefix{}).Next(); err == nil {
+		t.Fatal("invalid prefix should fail")
+	}
+}
+
+func TestAddrPoolFilter(t *testing.T) {
+	pool := NewAddrPoolUsable(netip.MustParsePrefix("192.168.1.248/29"))
+	pool.SetFilter(func(a netip.Addr) bool {
+		return a.As4()[3]%2 == 0
+	})
+	addrs := []netip.Addr{}
+	for {
+		addr, err := pool.Next()
+		if err != nil {
+			if errors.Is(err, ErrPoolExhausted) {
+				break
+			}
+			t.Fatal(err)
+		}
+		addrs = append(addrs, addr)
+	}
+	expected := "[192.168.1.250 192.168.1.252 192.168.1.254]"
+	if fmt.Sprint(addrs) != expected {
+		t.Fatalf("result %v is different from expected %v", addrs, expected)
+	}
+	pool = NewAddrPool(netip.MustParsePrefix("10.0.0.0/24"))
+	pool.SetFilter(func(netip.Addr) bool { return false })
+	if _, err := pool.Next(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("fully filtered pool should return ErrPoolExhausted, got %v", err)
+	}
+}
+
+func TestPrefixPool(t *testing.T) {
+	pool := NewPrefixPool(netip.MustParsePrefix("192.168.1.0/24"))
+	allocate := func(bits int, expected string) {
+		p, err := pool.Allocate(bits)
+		if err != nil {
+			if expected == "" {
+				t.Logf("allocating /%d failed as expected, %v", bits, err)
+				return
+			}
+			t.Fatalf("failed to allocate /%d, %v", bits, err)
+		}
+		if p.String() != expected {
+			t.Fatalf("allocated %v, expected %v", p, expected)
+		}
+	}
+	allocate(26, "192.168.1.0/26")
+	allocate(25, "192.168.1.128/25")
+	allocate(27, "192.168.1.64/27")
+	allocate(26, "")
+	allocate(23, "")
+	allocate(33, "")
+	if err := pool.Release(netip.MustParsePrefix("192.168.1.0/26")); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Release(netip.MustParsePrefix("192.168.1.0/26")); err == nil {
+		t.Fatal("releasing a prefix not allocated should fail")
+	}
+	allocate(28, "192.168.1.0/28")
+	allocate(26, "")
+	allocate(27, "192.168.1.32/27")
+	allocate(28, "192.168.1.16/28")
+	allocate(32, "192.168.1.96/32")
+	if _, err := pool.Allocate(27); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expect ErrPoolExhausted, got %v", err)
+	}
+
+	pool = NewPrefixPool(netip.MustParsePrefix("2001:dead:beef::/48"))
+	for _, expected := range []string{"2001:dead:beef::/64", "2001:dead:beef:1::/64", "2001:dead:beef:2::/64"} {
+		p, err := pool.Allocate(64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.String() != expected {
+			t.Fatalf("allocated %v, expected %v", p, expected)
+		}
+	}
+}
+
+type testPackPrefixesCase struct {
+	parent     string
+	sizes      []int
+	expected   string
+	shouldFail bool
+}
+
+func TestPackPrefixes(t *testing.T) {
+	testData := []testPackPrefixesCase{
+		{
+			parent:   "192.168.1.0/24",
+			sizes:    []int{26, 26, 25},
+			expected: "[192.168.1.0/26 192.168.1.64/26 192.168.1.128/25]",
+		},
+		{
+			parent:   "192.168.1.0/24",
+			sizes:    []int{26, 25, 26},
+			expected: "[192.168.1.0/26 192.168.1.128/25 192.168.1.64/26]",
+		},
+		{
+			parent:   "2001:dead::/48",
+			sizes:    []int{64, 56, 64},
+			expected: "[2001:dead::/64 2001:dead:0:100::/56 2001:dead:0:1::/64]",
+		},
+		{
+			parent:   "192.168.1.0/24",
+			sizes:    []int{},
+			expected: "[]",
+		},
+		{
+			parent:     "192.168.1.0/24",
+			sizes:      []int{26, 25, 25},
+			shouldFail: true,
+		},
+		{
+			parent:     "192.168.1.0/24",
+			sizes:      []int{23},
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testPackPrefixesCase) error {
+		r, err := PackPrefixes(netip.MustParsePrefix(c.parent), c.sizes)
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(r) != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if _, err := PackPrefixes(netip.MustParsePrefix("192.168.1.0/24"), []int{25, 25, 32}); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expect ErrPoolExhausted, got %v", err)
+	}
+}
+
+func TestUsableHosts(t *testing.T) {
+	testData := []struct {
+		prefix   string
+		expected string
+	}{
+		{"192.168.1.0/29", "[192.168.1.1 192.168.1.2 192.168.1.3 192.168.1.4 192.168.1.5 192.168.1.6]"},
+		{"192.168.1.0/31", "[192.168.1.0 192.168.1.1]"},
+		{"192.168.1.1/32", "[192.168.1.1]"},
+		{"2001:dead::/126", "[2001:dead:: 2001:dead::1 2001:dead::2 2001:dead::3]"},
+	}
+	for i, c := range testData {
+		r, err := UsableHosts(netip.MustParsePrefix(c.prefix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(r) != c.expected {
+			t.Fatalf("case %d: result %v is different from expected %v", i, r, c.expected)
+		}
+	}
+	if _, err := UsableHosts(netip.MustParsePrefix("2001:dead::/64")); err == nil {
+		t.Fatal("/64 should exceed the limit")
+	}
+	orig := MaxUsableHosts
+	defer func() { MaxUsableHosts = orig }()
+	MaxUsableHosts = 6
+	if _, err := UsableHosts(netip.MustParsePrefix("192.168.1.0/29")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UsableHosts(netip.MustParsePrefix("192.168.1.0/28")); err == nil {
+		t.Fatal("/28 should exceed the limit of 6")
+	}
+	MaxUsableHosts = 0
+	if _, err := UsableHosts(netip.MustParsePrefix("192.168.1.1/32")); err == nil {
+		t.Fatal("non-positive MaxUsableHosts should fail")
+	}
+}
+
+func TestMultiPool(t *testing.T) {
+	mp, err := NewMultiPool([]netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/31"),
+		netip.MustParsePrefix("10.0.1.0/30"),
+		netip.MustParsePrefix("2001:dead::/127"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := []netip.Addr{}
+	for {
+		addr, err := mp.Next()
+		if errors.Is(err, ErrPoolExhausted) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = append(r, addr)
+	}
+	//first pool is exhausted after 2 rounds, the third one after 2 rounds, the second one after 4
+	expected := "[10.0.0.0 10.0.1.0 2001:dead:: 10.0.0.1 10.0.1.1 2001:dead::1 10.0.1.2 10.0.1.3]"
+	if fmt.Sprint(r) != expected {
+		t.Fatalf("result %v is different from expected %v", r, expected)
+	}
+	if _, err := mp.Next(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expect ErrPoolExhausted, got %v", err)
+	}
+	if _, err := NewMultiPool([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/24"), {}}); err == nil {
+		t.Fatal("invalid prefix should fail")
+	}
+	empty, err := NewMultiPool(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := empty.Next(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expect ErrPoolExhausted from empty pool, got %v", err)
+	}
+}
+
+func TestMultiPoolConcurrent(t *testing.T) {
+	mp, err := NewMultiPool([]netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("10.0.1.0/25"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := make(chan netip.Addr, 384)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				addr, err := mp.Next()
+				if err != nil {
+					return
+				}
+				results <- addr
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+	seen := map[netip.Addr]bool{}
+	for addr := range results {
+		if seen[addr] {
+			t.Fatalf("%v is returned more than once", addr)
+		}
+		seen[addr] = true
+	}
+	if len(seen) != 384 {
+		t.Fatalf("got %d addresses, expect 384", len(seen))
+	}
+}
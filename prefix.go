@@ -0,0 +1,863 @@
+// Copyright 2020 Hu Jun. All rights reserved.
+// This project is licensed under the terms of the MIT license.
+// license that can be found in the LICENSE file.
+
+package myaddr
+
+import (
+	"fmt"
+	"hash/fnv"
+	"iter"
+	"math/big"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// netipToBig convert addr to *big.Int, zone is ignored
+func netipToBig(addr netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(addr.AsSlice())
+}
+
+// bigToNetip convert n to an IPv4 netip.Addr if bitlen is 32, IPv6 otherwise
+func bigToNetip(n *big.Int, bitlen int) (netip.Addr, error) {
+	if n.Sign() < 0 || n.BitLen() > bitlen {
+		return netip.Addr{}, fmt.Errorf("%v is out of range for a %d bit address", n, bitlen)
+	}
+	buf := make([]byte, bitlen/8)
+	n.FillBytes(buf)
+	r, ok := netip.AddrFromSlice(buf)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("invalid address bit length %d", bitlen)
+	}
+	return r, nil
+}
+
+// sameFamily return error if a and b are not valid addresses of same address family
+func sameFamily(a, b netip.Addr) error {
+	if !a.IsValid() || !b.IsValid() {
+		return fmt.Errorf("invalid address %v or %v", a, b)
+	}
+	if a.Is4() != b.Is4() {
+		return fmt.Errorf("%v and %v are not in same address family", a, b)
+	}
+	return nil
+}
+
+// PrefixesOverlap return true if address range of a and b intersect,
+// i.e. a contains b, b contains a or they are equal.
+// a and b in different address family never overlap
+func PrefixesOverlap(a, b netip.Prefix) bool {
+	return a.Overlaps(b)
+}
+
+// PrefixIntersection return the intersection of a and b, which is the more specific one (with host bits cleared)
+// if one contains the other, since two prefixes are either nested or disjoint;
+// return false if a and b are disjoint or in different address family
+func PrefixIntersection(a, b netip.Prefix) (netip.Prefix, bool) {
+	if !a.Overlaps(b) {
+		return netip.Prefix{}, false
+	}
+	if a.Bits() >= b.Bits() {
+		return a.Masked(), true
+	}
+	return b.Masked(), true
+}
+
+// DedupPrefixes return prefixes with duplicates and the ones contained in another prefix removed,
+// surviving prefixes keep their input order; prefixes are compared with host bits cleared,
+// and the first one of duplicates is kept. invalid prefixes are dropped.
+// unlike FindGaps or AddrSet, adjacent prefixes are not merged, so boundaries of survivors are not changed
+func DedupPrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	r := []netip.Prefix{}
+	for i, p := range prefixes {
+		if !p.IsValid() {
+			continue
+		}
+		keep := true
+		for j, other := range prefixes {
+			if i == j || !other.IsValid() || !other.Overlaps(p) {
+				continue
+			}
+			//other contains p, or other is a duplicate of p appearing earlier
+			if other.Bits() < p.Bits() || (other.Bits() == p.Bits() && j < i) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// PrefixGap return number of addresses between a and b, i.e. between the last address of the lower one
+// and the first address of the higher one, 0 if they are adjacent; a and b could be in any order.
+// return error if a and b overlap, or are not in same address family
+func PrefixGap(a, b netip.Prefix) (*big.Int, error) {
+	if !a.IsValid() || !b.IsValid() {
+		return nil, fmt.Errorf("invalid prefix %v or %v", a, b)
+	}
+	if err := sameFamily(a.Addr(), b.Addr()); err != nil {
+		return nil, err
+	}
+	if a.Overlaps(b) {
+		return nil, fmt.Errorf("%v and %v overlap", a, b)
+	}
+	ar, br := prefixToBigRange(a), prefixToBigRange(b)
+	if ar.start.Cmp(br.start) > 0 {
+		ar, br = br, ar
+	}
+	r := new(big.Int).Sub(br.start, ar.end)
+	return r.Sub(r, big.NewInt(1)), nil
+}
+
+// RangeToPrefixes return the minimal list of prefixes that exactly cover
+// address range [start, end], in ascending order.
+// start and end must be in same address family and start <= end
+func RangeToPrefixes(start, end netip.Addr) ([]netip.Prefix, error) {
+	if err := sameFamily(start, end); err != nil {
+		return nil, err
+	}
+	if start.Compare(end) > 0 {
+		return nil, fmt.Errorf("start %v is bigger than end %v", start, end)
+	}
+	bitlen := start.BitLen()
+	cur := netipToBig(start)
+	endn := netipToBig(end)
+	r := []netip.Prefix{}
+	for cur.Cmp(endn) <= 0 {
+		hostbits := bitlen
+		if cur.Sign() != 0 {
+			hostbits = int(cur.TrailingZeroBits())
+		}
+		var size *big.Int
+		for {
+			size = new(big.Int).Lsh(big.NewInt(1), uint(hostbits))
+			last := new(big.Int).Add(cur, size)
+			if last.Sub(last, big.NewInt(1)).Cmp(endn) <= 0 {
+				break
+			}
+			hostbits--
+		}
+		addr, err := bigToNetip(cur, bitlen)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, netip.PrefixFrom(addr, bitlen-hostbits))
+		cur.Add(cur, size)
+	}
+	return r, nil
+}
+
+// PrefixRange return the first (network) and last (broadcast) address of prefix,
+// first <= last is always true for a valid prefix;
+// both are zero netip.Addr if prefix is invalid
+func PrefixRange(prefix netip.Prefix) (first, last netip.Addr) {
+	if !prefix.IsValid() {
+		return netip.Addr{}, netip.Addr{}
+	}
+	first = prefix.Masked().Addr()
+	b := first.AsSlice()
+	for i := prefix.Bits(); i < first.BitLen(); i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	last, _ = netip.AddrFromSlice(b)
+	return first, last
+}
+
+// NetMask return the network mask of prefix as an address, i.e. network bits set and host bits clear,
+// e.g. 255.255.255.0 for a /24; return zero netip.Addr if prefix is invalid
+func NetMask(prefix netip.Prefix) netip.Addr {
+	if !prefix.IsValid() {
+		return netip.Addr{}
+	}
+	r, _ := netip.AddrFromSlice(net.CIDRMask(prefix.Bits(), prefix.Addr().BitLen()))
+	return r
+}
+
+// HostMask return the host mask (wildcard mask) of prefix as an address, i.e. host bits set and network bits clear,
+// e.g. 0.0.0.255 for a /24; return zero netip.Addr if prefix is invalid
+func HostMask(prefix netip.Prefix) netip.Addr {
+	if !prefix.IsValid() {
+		return netip.Addr{}
+	}
+	b := net.CIDRMask(prefix.Bits(), prefix.Addr().BitLen())
+	for i := range b {
+		b[i] = ^b[i]
+	}
+	r, _ := netip.AddrFromSlice(b)
+	return r
+}
+
+// hostCount return number of addresses in prefix
+func hostCount(prefix netip.Prefix) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(prefix.Addr().BitLen()-prefix.Bits()))
+}
+
+// IncHostInPrefix increase addr by step (could be negative) within prefix,
+// the result wraps around if it goes beyond the boundary of prefix,
+// in which case the returned bool is true.
+// addr must be contained in prefix, zone of addr is ignored for containment and kept in the result
+func IncHostInPrefix(prefix netip.Prefix, addr netip.Addr, step *big.Int) (netip.Addr, bool, error) {
+	if !prefix.IsValid() {
+		return netip.Addr{}, false, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	if !prefix.Contains(addr.WithZone("")) {
+		return netip.Addr{}, false, fmt.Errorf("%v is not in prefix %v", addr, prefix)
+	}
+	network := netipToBig(prefix.Masked().Addr())
+	size := hostCount(prefix)
+	offset := new(big.Int).Sub(netipToBig(addr), network)
+	offset.Add(offset, step)
+	wrapped := offset.Sign() < 0 || offset.Cmp(size) >= 0
+	offset.Mod(offset, size)
+	r, err := bigToNetip(offset.Add(offset, network), addr.BitLen())
+	if err != nil {
+		return netip.Addr{}, false, err
+	}
+	return r.WithZone(addr.Zone()), wrapped, nil
+}
+
+// StepStaysInPrefix return true if addr + step (could be negative) is contained in prefix,
+// without generating the result address; zone of addr is ignored.
+// addr and prefix must be in same address family
+func StepStaysInPrefix(prefix netip.Prefix, addr netip.Addr, step *big.Int) (bool, error) {
+	if !prefix.IsValid() {
+		return false, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	if err := sameFamily(addr, prefix.Addr()); err != nil {
+		return false, err
+	}
+	pr := prefixToBigRange(prefix)
+	rn := new(big.Int).Add(netipToBig(addr), step)
+	return rn.Cmp(pr.start) >= 0 && rn.Cmp(pr.end) <= 0, nil
+}
+
+// IsNetworkAddr return true if addr is the network address (all host bits are zero) of prefix;
+// always return false if addr is not in prefix, or prefix is a /31, /32, /127 or /128,
+// since there is no distinct network address in such prefix; zone of addr is ignored
+func IsNetworkAddr(prefix netip.Prefix, addr netip.Addr) bool {
+	addr = addr.WithZone("")
+	if !prefix.Contains(addr) || prefix.Addr().BitLen()-prefix.Bits() < 2 {
+		return false
+	}
+	first, _ := PrefixRange(prefix)
+	return addr == first
+}
+
+// IsBroadcastAddr return true if addr is the broadcast address (all host bits are one) of IPv4 prefix;
+// always return false if addr is not in prefix, prefix is a /31 or /32, or prefix is IPv6,
+// since there is no broadcast address in such prefix
+func IsBroadcastAddr(prefix netip.Prefix, addr netip.Addr) bool {
+	if !prefix.Addr().Is4() || !prefix.Contains(addr) || prefix.Bits() > 30 {
+		return false
+	}
+	_, last := PrefixRange(prefix)
+	return addr == last
+}
+
+// IsHostZero return true if addr is in prefix and all its host bits are zero, i.e. it is the network address;
+// zone of addr is ignored; unlike IsNetworkAddr, it doesn't treat /31, /32, /127 or /128 specially
+func IsHostZero(prefix netip.Prefix, addr netip.Addr) bool {
+	return prefix.Contains(addr.WithZone("")) && HostBitsZero(addr, prefix.Bits())
+}
+
+// HostBitsZero return true if all bits of addr after the first prefixBits bits are zero,
+// zone of addr is ignored; return false if addr is invalid or prefixBits is out of range
+func HostBitsZero(addr netip.Addr, prefixBits int) bool {
+	p, err := addr.Prefix(prefixBits)
+	if err != nil || !addr.IsValid() {
+		return false
+	}
+	return p.Addr() == addr.WithZone("")
+}
+
+// AlignDown return the network address of the bits-length prefix containing addr,
+// i.e. addr with host bits cleared; zone of addr is dropped
+func AlignDown(addr netip.Addr, bits int) (netip.Addr, error) {
+	p, err := addr.Prefix(bits)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if !p.IsValid() {
+		return netip.Addr{}, fmt.Errorf("invalid address %v", addr)
+	}
+	return p.Addr(), nil
+}
+
+// AlignUp return the smallest address >= addr that is the network address of a bits-length prefix,
+// which is addr itself if it is already aligned; zone of addr is dropped.
+// return error if there is no such address, e.g. 255.255.255.1 to /24
+func AlignUp(addr netip.Addr, bits int) (netip.Addr, error) {
+	down, err := AlignDown(addr, bits)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if down == addr.WithZone("") {
+		return down, nil
+	}
+	return NextPrefixAddr(netip.PrefixFrom(down, bits))
+}
+
+// SameSubnet return true if a and b are in same bits-length prefix, zone is ignored;
+// a and b must be in same address family
+func SameSubnet(a, b netip.Addr, bits int) (bool, error) {
+	if err := sameFamily(a, b); err != nil {
+		return false, err
+	}
+	an, err := AlignDown(a, bits)
+	if err != nil {
+		return false, err
+	}
+	bn, err := AlignDown(b, bits)
+	if err != nil {
+		return false, err
+	}
+	return an == bn, nil
+}
+
+// SplitAddr return the network address of the prefixBits-length prefix containing addr,
+// and the host offset of addr in that prefix, e.g. 10.1.2.3 and 24 -> 10.1.2.0 and 3; zone of addr is dropped
+func SplitAddr(addr netip.Addr, prefixBits int) (network netip.Addr, hostIndex *big.Int, err error) {
+	network, err = AlignDown(addr, prefixBits)
+	if err != nil {
+		return netip.Addr{}, nil, err
+	}
+	hostIndex = netipToBig(addr)
+	return network, hostIndex.Sub(hostIndex, netipToBig(network)), nil
+}
+
+// RelocateHost return the address in toPrefix at same host offset as addr in its fromBits-length prefix,
+// e.g. 10.0.0.5 with fromBits 24 to 192.168.1.0/24 -> 192.168.1.5;
+// addr and toPrefix must be in same address family, and the offset must fit into host bits of toPrefix
+func RelocateHost(addr netip.Addr, fromBits int, toPrefix netip.Prefix) (netip.Addr, error) {
+	if !toPrefix.IsValid() {
+		return netip.Addr{}, fmt.Errorf("invalid prefix %v", toPrefix)
+	}
+	if err := sameFamily(addr, toPrefix.Addr()); err != nil {
+		return netip.Addr{}, err
+	}
+	_, hostIndex, err := SplitAddr(addr, fromBits)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	r, err := GenPrefixWithPrefix(toPrefix, hostIndex)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return r.Addr(), nil
+}
+
+// DescribePrefix return a human readable description of prefix with its size and address range, like
+// "192.168.1.0/24 (256 addresses, 254 usable, 192.168.1.1-192.168.1.254)";
+// the usable count and range excluding network and broadcast address are only for IPv4 prefix with
+// prefix length <= 30, all addresses are used for other prefixes, like "2001:db8::/127 (2 addresses, 2001:db8::-2001:db8::1)"
+func DescribePrefix(prefix netip.Prefix) string {
+	if !prefix.IsValid() {
+		return prefix.String()
+	}
+	prefix = prefix.Masked()
+	first, last := PrefixRange(prefix)
+	if first == last {
+		return fmt.Sprintf("%v (1 address, %v)", prefix, first)
+	}
+	count := hostCount(prefix)
+	if !prefix.Addr().Is4() || prefix.Bits() > 30 {
+		return fmt.Sprintf("%v (%v addresses, %v-%v)", prefix, count, first, last)
+	}
+	usable := new(big.Int).Sub(count, big.NewInt(2))
+	return fmt.Sprintf("%v (%v addresses, %v usable, %v-%v)", prefix, count, usable, first.Next(), last.Prev())
+}
+
+// PrefixHost is the Index-th host address in Prefix,
+// its text form is "<prefix>#<index>", like "10.0.0.0/24#5"
+type PrefixHost struct {
+	Prefix netip.Prefix
+	Index  *big.Int
+}
+
+// Addr return the address PrefixHost refers to,
+// return error if Prefix is invalid or Index is out of range of Prefix
+func (ph PrefixHost) Addr() (netip.Addr, error) {
+	if !ph.Prefix.IsValid() {
+		return netip.Addr{}, fmt.Errorf("invalid prefix %v", ph.Prefix)
+	}
+	if ph.Index == nil {
+		return netip.Addr{}, fmt.Errorf("index is not set")
+	}
+	r, err := GenPrefixWithPrefix(ph.Prefix, ph.Index)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return r.Addr(), nil
+}
+
+// MarshalText implements encoding.TextMarshaler interface
+func (ph PrefixHost) MarshalText() ([]byte, error) {
+	if ph.Index == nil {
+		return nil, fmt.Errorf("index is not set")
+	}
+	return []byte(ph.Prefix.String() + "#" + ph.Index.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface
+func (ph *PrefixHost) UnmarshalText(text []byte) error {
+	fields := strings.Split(string(text), "#")
+	if len(fields) != 2 {
+		return fmt.Errorf("%v is not in format of <prefix>#<index>", string(text))
+	}
+	prefix, err := netip.ParsePrefix(fields[0])
+	if err != nil {
+		return err
+	}
+	index, ok := new(big.Int).SetString(fields[1], 10)
+	if !ok || index.Sign() < 0 {
+		return fmt.Errorf("invalid index %v", fields[1])
+	}
+	ph.Prefix = prefix
+	ph.Index = index
+	return nil
+}
+
+// HostBitsFor return minimal number of host bits needed to address n hosts,
+// i.e. ceil(log2(n)); return 0 if n <= 1
+func HostBitsFor(n *big.Int) int {
+	if n.Cmp(big.NewInt(1)) <= 0 {
+		return 0
+	}
+	return new(big.Int).Sub(n, big.NewInt(1)).BitLen()
+}
+
+// PrefixLenFor return prefix length of the smallest IPv4 prefix if ipv4 is true,
+// IPv6 prefix otherwise, that holds n hosts;
+// return error if n is negative or exceeds size of address space
+func PrefixLenFor(n *big.Int, ipv4 bool) (int, error) {
+	if n.Sign() < 0 {
+		return 0, fmt.Errorf("%v is negative", n)
+	}
+	bitlen := 128
+	if ipv4 {
+		bitlen = 32
+	}
+	hostbits := HostBitsFor(n)
+	if hostbits > bitlen {
+		return 0, fmt.Errorf("%v exceeds size of %d bit address space", n, bitlen)
+	}
+	return bitlen - hostbits, nil
+}
+
+// ExactPrefixLen return prefix length of the IPv4 prefix if ipv4 is true, IPv6 prefix otherwise,
+// that has exactly hostCount addresses, e.g. 256 -> 24 for IPv4;
+// unlike PrefixLenFor, return error if hostCount is not a power of 2, or exceeds size of address space
+func ExactPrefixLen(hostCount *big.Int, ipv4 bool) (int, error) {
+	if hostCount.Sign() <= 0 {
+		return 0, fmt.Errorf("%v is not positive", hostCount)
+	}
+	if new(big.Int).And(hostCount, new(big.Int).Sub(hostCount, big.NewInt(1))).Sign() != 0 {
+		return 0, fmt.Errorf("%v is not a power of 2", hostCount)
+	}
+	return PrefixLenFor(hostCount, ipv4)
+}
+
+// bigRange is an address range [start, end] represented as *big.Int
+type bigRange struct {
+	start, end *big.Int
+}
+
+// prefixToBigRange return address range of prefix
+func prefixToBigRange(prefix netip.Prefix) bigRange {
+	first, last := PrefixRange(prefix)
+	return bigRange{start: netipToBig(first), end: netipToBig(last)}
+}
+
+// mergeRanges return a sorted list of non-overlapping, non-adjacent ranges
+// covering same addresses as rs
+func mergeRanges(rs []bigRange) []bigRange {
+	sorted := make([]bigRange, len(rs))
+	copy(sorted, rs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].start.Cmp(sorted[j].start) < 0
+	})
+	r := []bigRange{}
+	for _, cur := range sorted {
+		if len(r) > 0 {
+			last := &r[len(r)-1]
+			next := new(big.Int).Add(last.end, big.NewInt(1))
+			if cur.start.Cmp(next) <= 0 {
+				if cur.end.Cmp(last.end) > 0 {
+					last.end = cur.end
+				}
+				continue
+			}
+		}
+		r = append(r, bigRange{start: cur.start, end: cur.end})
+	}
+	return r
+}
+
+// rangesToPrefixes return minimal list of prefixes covering rs, rs must be sorted
+func rangesToPrefixes(rs []bigRange, bitlen int) ([]netip.Prefix, error) {
+	r := []netip.Prefix{}
+	for _, br := range rs {
+		start, err := bigToNetip(br.start, bitlen)
+		if err != nil {
+			return nil, err
+		}
+		end, err := bigToNetip(br.end, bitlen)
+		if err != nil {
+			return nil, err
+		}
+		prefixes, err := RangeToPrefixes(start, end)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, prefixes...)
+	}
+	return r, nil
+}
+
+// FindGaps return the minimal list of prefixes inside within that are not covered by any of prefixes,
+// in ascending order; prefixes could overlap with each other, or partially outside within.
+// all prefixes must be in same address family as within
+func FindGaps(prefixes []netip.Prefix, within netip.Prefix) ([]netip.Prefix, error) {
+	if !within.IsValid() {
+		return nil, fmt.Errorf("invalid prefix %v", within)
+	}
+	withinRange := prefixToBigRange(within)
+	used := []bigRange{}
+	for _, p := range prefixes {
+		if !p.IsValid() || p.Addr().Is4() != within.Addr().Is4() {
+			return nil, fmt.Errorf("%v is not a valid prefix in same address family as %v", p, within)
+		}
+		if !p.Overlaps(within) {
+			continue
+		}
+		pr := prefixToBigRange(p)
+		//clip to within
+		if pr.start.Cmp(withinRange.start) < 0 {
+			pr.start = withinRange.start
+		}
+		if pr.end.Cmp(withinRange.end) > 0 {
+			pr.end = withinRange.end
+		}
+		used = append(used, pr)
+	}
+	gaps := []bigRange{}
+	cur := withinRange.start
+	for _, u := range mergeRanges(used) {
+		if u.start.Cmp(cur) > 0 {
+			gaps = append(gaps, bigRange{start: cur, end: new(big.Int).Sub(u.start, big.NewInt(1))})
+		}
+		cur = new(big.Int).Add(u.end, big.NewInt(1))
+	}
+	if cur.Cmp(withinRange.end) <= 0 {
+		gaps = append(gaps, bigRange{start: cur, end: withinRange.end})
+	}
+	return rangesToPrefixes(gaps, within.Addr().BitLen())
+}
+
+// PrefixComplement return the minimal list of prefixes covering parent minus child, in ascending order,
+// e.g. 10.0.0.0/24 minus 10.0.0.0/26 is 10.0.0.64/26 and 10.0.0.128/25; child must be contained in parent
+func PrefixComplement(parent, child netip.Prefix) ([]netip.Prefix, error) {
+	if !parent.IsValid() || !child.IsValid() {
+		return nil, fmt.Errorf("invalid prefix %v or %v", parent, child)
+	}
+	if child.Bits() < parent.Bits() || !parent.Contains(child.Addr()) {
+		return nil, fmt.Errorf("%v is not contained in %v", child, parent)
+	}
+	return FindGaps([]netip.Prefix{child}, parent)
+}
+
+// NextPrefixAddr return the first address after prefix, i.e. broadcast address + 1;
+// return error if prefix reaches the max address
+func NextPrefixAddr(prefix netip.Prefix) (netip.Addr, error) {
+	if !prefix.IsValid() {
+		return netip.Addr{}, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	_, last := PrefixRange(prefix)
+	return IncAddrKeepZone(last, big.NewInt(1))
+}
+
+// PrevPrefixAddr return the last address before prefix, i.e. network address - 1;
+// return error if prefix starts from the zero address
+func PrevPrefixAddr(prefix netip.Prefix) (netip.Addr, error) {
+	if !prefix.IsValid() {
+		return netip.Addr{}, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	first, _ := PrefixRange(prefix)
+	return IncAddrKeepZone(first, big.NewInt(-1))
+}
+
+// ParsePrefixCanonical parse prefix string s, return the canonical form with host bits cleared,
+// e.g. "192.168.1.5/24" -> 192.168.1.0/24
+func ParsePrefixCanonical(s string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+// ParsePrefixStrict parse prefix string s, return error if any host bit is set,
+// e.g. "192.168.1.5/24" is an error
+func ParsePrefixStrict(s string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if prefix.Masked() != prefix {
+		return netip.Prefix{}, fmt.Errorf("%v has host bits set, expect %v", s, prefix.Masked())
+	}
+	return prefix, nil
+}
+
+// ParseAddrOrPrefix parse s as either an address like "10.0.0.1" or a prefix like "10.0.0.1/24",
+// isPrefix is true if s is a prefix; addr is the address part of s, prefix is the prefix as is,
+// or a /32 or /128 prefix of addr (zone dropped) if s is an address
+func ParseAddrOrPrefix(s string) (addr netip.Addr, prefix netip.Prefix, isPrefix bool, err error) {
+	if strings.Contains(s, "/") {
+		prefix, err = netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Addr{}, netip.Prefix{}, false, err
+		}
+		return prefix.Addr(), prefix, true, nil
+	}
+	addr, err = netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, netip.Prefix{}, false, err
+	}
+	return addr, netip.PrefixFrom(addr.WithZone(""), addr.BitLen()), false, nil
+}
+
+// RequirePrefixLen return error if prefix is invalid or its prefix length is not one of allowed,
+// e.g. RequirePrefixLen(p, 64) before GenSLAACAddr, RequirePrefixLen(p, 31, 127) for a point-to-point link
+func RequirePrefixLen(prefix netip.Prefix, allowed ...int) error {
+	if !prefix.IsValid() {
+		return fmt.Errorf("invalid prefix %v", prefix)
+	}
+	for _, bits := range allowed {
+		if prefix.Bits() == bits {
+			return nil
+		}
+	}
+	return fmt.Errorf("prefix length of %v is not one of %v", prefix, allowed)
+}
+
+// IsValidHostSubnet return true if prefix follows common rules of a subnet for hosts:
+// an IPv4 prefix must be /30 or shorter, or a /31 point-to-point prefix (RFC 3021);
+// an IPv6 prefix must be a /64 as required by SLAAC, or a /127 point-to-point prefix (RFC 6164).
+// these are conventions, use RequirePrefixLen for a specific requirement
+func IsValidHostSubnet(prefix netip.Prefix) bool {
+	if !prefix.IsValid() {
+		return false
+	}
+	if prefix.Addr().Is4() {
+		return prefix.Bits() <= 30 || RequirePrefixLen(prefix, 31) == nil
+	}
+	return RequirePrefixLen(prefix, 64, 127) == nil
+}
+
+// SubnetCount return number of sub-prefixes with prefix length subBits in parent, i.e. 2^(subBits-parent.Bits())
+func SubnetCount(parent netip.Prefix, subBits int) (*big.Int, error) {
+	if !parent.IsValid() {
+		return nil, fmt.Errorf("invalid prefix %v", parent)
+	}
+	if subBits < parent.Bits() || subBits > parent.Addr().BitLen() {
+		return nil, fmt.Errorf("invalid prefix length %d for sub-prefix of %v", subBits, parent)
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(subBits-parent.Bits())), nil
+}
+
+// NthSubnet return the index-th (starting from 0) sub-prefix with prefix length newBits in parent
+func NthSubnet(parent netip.Prefix, newBits, index int) (netip.Prefix, error) {
+	if !parent.IsValid() {
+		return netip.Prefix{}, fmt.Errorf("invalid prefix %v", parent)
+	}
+	bitlen := parent.Addr().BitLen()
+	if newBits < parent.Bits() || newBits > bitlen {
+		return netip.Prefix{}, fmt.Errorf("invalid prefix length %d for sub-prefix of %v", newBits, parent)
+	}
+	if index < 0 || big.NewInt(int64(index)).BitLen() > newBits-parent.Bits() {
+		return netip.Prefix{}, fmt.Errorf("index %d is out of range for /%d in %v", index, newBits, parent)
+	}
+	n := new(big.Int).Lsh(big.NewInt(int64(index)), uint(bitlen-newBits))
+	n.Add(n, netipToBig(parent.Masked().Addr()))
+	addr, err := bigToNetip(n, bitlen)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, newBits), nil
+}
+
+// Supernet return the immediate parent of prefix, i.e. the prefix one bit shorter containing it,
+// e.g. 10.0.1.0/24 -> 10.0.0.0/23; return error if prefix is a /0
+func Supernet(prefix netip.Prefix) (netip.Prefix, error) {
+	if !prefix.IsValid() {
+		return netip.Prefix{}, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	if prefix.Bits() == 0 {
+		return netip.Prefix{}, fmt.Errorf("%v has no supernet", prefix)
+	}
+	return prefix.Addr().Prefix(prefix.Bits() - 1)
+}
+
+// SiblingPrefix return the other half of the supernet of prefix, i.e. prefix with last network bit flipped,
+// e.g. 10.0.1.0/24 -> 10.0.0.0/24; return error if prefix is a /0
+func SiblingPrefix(prefix netip.Prefix) (netip.Prefix, error) {
+	if !prefix.IsValid() {
+		return netip.Prefix{}, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	if prefix.Bits() == 0 {
+		return netip.Prefix{}, fmt.Errorf("%v has no sibling", prefix)
+	}
+	b := prefix.Masked().Addr().AsSlice()
+	i := prefix.Bits() - 1
+	b[i/8] ^= 0x80 >> (i % 8)
+	addr, _ := netip.AddrFromSlice(b)
+	return netip.PrefixFrom(addr, prefix.Bits()), nil
+}
+
+// SubPrefixSeq return an iterator over all sub-prefixes with prefix length subBits in parent,
+// in ascending order, e.g. all /64 in a /48; sub-prefixes are generated lazily one by one,
+// so memory usage is constant regardless of the number of sub-prefixes.
+// if parent or subBits is invalid, the iterator yields a single error
+func SubPrefixSeq(parent netip.Prefix, subBits int) iter.Seq2[netip.Prefix, error] {
+	return func(yield func(netip.Prefix, error) bool) {
+		if !parent.IsValid() {
+			yield(netip.Prefix{}, fmt.Errorf("invalid prefix %v", parent))
+			return
+		}
+		bitlen := parent.Addr().BitLen()
+		if subBits < parent.Bits() || subBits > bitlen {
+			yield(netip.Prefix{}, fmt.Errorf("invalid prefix length %d for sub-prefix of %v", subBits, parent))
+			return
+		}
+		pr := prefixToBigRange(parent)
+		size := new(big.Int).Lsh(big.NewInt(1), uint(bitlen-subBits))
+		for cur := pr.start; cur.Cmp(pr.end) <= 0; cur = new(big.Int).Add(cur, size) {
+			addr, err := bigToNetip(cur, bitlen)
+			if err != nil {
+				yield(netip.Prefix{}, err)
+				return
+			}
+			if !yield(netip.PrefixFrom(addr, subBits), nil) {
+				return
+			}
+		}
+	}
+}
+
+// EnclosingPrefixes return every prefix that contains addr, from the most specific (/32 or /128) to /0,
+// zone of addr is dropped; return nil if addr is invalid
+func EnclosingPrefixes(addr netip.Addr) []netip.Prefix {
+	if !addr.IsValid() {
+		return nil
+	}
+	r := make([]netip.Prefix, 0, addr.BitLen()+1)
+	for bits := addr.BitLen(); bits >= 0; bits-- {
+		p, _ := addr.Prefix(bits)
+		r = append(r, p)
+	}
+	return r
+}
+
+// SampleAddrs return n addresses evenly spaced across prefix in ascending order,
+// the first and last address of prefix are always included if n >= 2;
+// all addresses of prefix are returned if n is bigger than number of addresses in prefix
+func SampleAddrs(prefix netip.Prefix, n int) ([]netip.Addr, error) {
+	if !prefix.IsValid() {
+		return nil, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("count %d is negative", n)
+	}
+	size := hostCount(prefix)
+	if size.Cmp(big.NewInt(int64(n))) < 0 {
+		n = int(size.Int64())
+	}
+	r := make([]netip.Addr, 0, n)
+	network := netipToBig(prefix.Masked().Addr())
+	last := new(big.Int).Sub(size, big.NewInt(1))
+	for i := 0; i < n; i++ {
+		//offset of i-th address is i*(size-1)/(n-1)
+		offset := big.NewInt(0)
+		if n > 1 {
+			offset.Mul(last, big.NewInt(int64(i)))
+			offset.Quo(offset, big.NewInt(int64(n-1)))
+		}
+		addr, err := bigToNetip(offset.Add(offset, network), prefix.Addr().BitLen())
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, addr)
+	}
+	return r, nil
+}
+
+// AddrFromKey return an address in prefix derived from key, the host index is FNV-1a hash of key
+// modulo number of addresses in prefix, so same key always results in same address;
+// different keys could result in same address, especially when prefix is small.
+// since the hash is 64 bit, only the first 2^64 addresses could be returned for a bigger IPv6 prefix
+func AddrFromKey(prefix netip.Prefix, key string) (netip.Addr, error) {
+	if !prefix.IsValid() {
+		return netip.Addr{}, fmt.Errorf("invalid prefix %v", prefix)
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	hostn := new(big.Int).SetUint64(h.Sum64())
+	r, err := GenPrefixWithPrefix(prefix, hostn.Mod(hostn, hostCount(prefix)))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return r.Addr(), nil
+}
+
+// PointToPointPair return the two addresses of a /31 IPv4 or /127 IPv6 point-to-point prefix
+func PointToPointPair(prefix netip.Prefix) (a, b netip.Addr, err error) {
+	if !prefix.IsValid() || prefix.Addr().BitLen()-prefix.Bits() != 1 {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("%v is not a /31 or /127 prefix", prefix)
+	}
+	pa, err := GenPrefixWithPrefix(prefix, big.NewInt(0))
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, err
+	}
+	pb, err := GenPrefixWithPrefix(prefix, big.NewInt(1))
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, err
+	}
+	return pa.Addr(), pb.Addr(), nil
+}
+
+// ContainedInAny return the most specific (longest) prefix in prefixes that contains addr,
+// and true if there is one; if multiple prefixes with same length contain addr,
+// the first one in prefixes is returned; zone of addr is ignored.
+// it does a linear scan, use PrefixTrie for large number of prefixes
+func ContainedInAny(addr netip.Addr, prefixes []netip.Prefix) (netip.Prefix, bool) {
+	var r netip.Prefix
+	found := false
+	addr = addr.WithZone("")
+	for _, p := range prefixes {
+		if p.Contains(addr) && (!found || p.Bits() > r.Bits()) {
+			r = p
+			found = true
+		}
+	}
+	return r, found
+}
+
+// GatewayAddr return the first host address of prefix, i.e. network address + 1,
+// which is conventionally used as the gateway address, though it is not a requirement;
+// return error if prefix is a /32 or /128
+func GatewayAddr(prefix netip.Prefix) (netip.Addr, error) {
+	if !prefix.IsValid() || prefix.Bits() == prefix.Addr().BitLen() {
+		return netip.Addr{}, fmt.Errorf("%v doesn't have a distinct gateway address", prefix)
+	}
+	r, err := GenPrefixWithPrefix(prefix, big.NewInt(1))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return r.Addr(), nil
+}
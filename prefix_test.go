@@ -0,0 +1,1789 @@
+// myaddr_test
+package myaddr
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"testing"
+)
+
+type testPrefixesOverlapCase struct {
+	a, b     string
+	expected bool
+}
+
+func TestPrefixesOverlap(t *testing.T) {
+	testData := []testPrefixesOverlapCase{
+		{
+			a:        "192.168.0.0/16",
+			b:        "192.168.1.0/24",
+			expected: true,
+		},
+		{
+			a:        "192.168.1.0/24",
+			b:        "192.168.0.0/16",
+			expected: true,
+		},
+		{
+			a:        "192.168.1.0/24",
+			b:        "192.168.1.0/24",
+			expected: true,
+		},
+		{
+			a:        "192.168.0.0/24",
+			b:        "192.168.1.0/24",
+			expected: false,
+		},
+		{
+			a:        "2001:dead::/32",
+			b:        "2001:dead:beef::/48",
+			expected: true,
+		},
+		{
+			a:        "0.0.0.0/0",
+			b:        "::/0",
+			expected: false,
+		},
+		{
+			a:        "10.0.0.0/8",
+			b:        "::ffff:10.0.0.0/104",
+			expected: false,
+		},
+	}
+	runTest := func(c testPrefixesOverlapCase) error {
+		r := PrefixesOverlap(netip.MustParsePrefix(c.a), netip.MustParsePrefix(c.b))
+		if r != c.expected {
+			return fmt.Errorf("overlap of %v and %v is %v, expected %v", c.a, c.b, r, c.expected)
+		}
+		return nil
+	}
+	for _, c := range testData {
+		if err := runTest(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type testPrefixIntersectionCase struct {
+	a, b          string
+	expected      string
+	expectedFound bool
+}
+
+func TestPrefixIntersection(t *testing.T) {
+	testData := []testPrefixIntersectionCase{
+		{"10.0.0.0/8", "10.1.2.0/24", "10.1.2.0/24", true},
+		{"10.1.2.3/24", "10.0.0.0/8", "10.1.2.0/24", true},
+		{"10.1.2.0/24", "10.1.2.0/24", "10.1.2.0/24", true},
+		{"10.1.2.0/24", "10.1.3.0/24", "", false},
+		{"2001:dead::/32", "2001:dead:beef::/48", "2001:dead:beef::/48", true},
+		{"::/0", "10.0.0.0/8", "", false},
+		{"0.0.0.0/0", "2001:dead::/32", "", false},
+	}
+	for i, c := range testData {
+		r, found := PrefixIntersection(netip.MustParsePrefix(c.a), netip.MustParsePrefix(c.b))
+		if found != c.expectedFound {
+			t.Fatalf("case %d: found %v is different from expected %v", i, found, c.expectedFound)
+		}
+		if found && r.String() != c.expected {
+			t.Fatalf("case %d: result %v is different from expected %v", i, r, c.expected)
+		}
+	}
+}
+
+func TestDedupPrefixes(t *testing.T) {
+	testData := []struct {
+		prefixes []string
+		expected string
+	}{
+		{
+			prefixes: []string{"10.0.1.0/24", "10.0.0.0/24", "10.0.1.0/24"},
+			expected: "[10.0.1.0/24 10.0.0.0/24]",
+		},
+		{
+			prefixes: []string{"10.0.1.0/24", "192.168.0.0/16", "10.0.0.0/8", "10.1.0.0/16", "192.168.1.0/24"},
+			expected: "[192.168.0.0/16 10.0.0.0/8]",
+		},
+		{
+			prefixes: []string{"10.0.0.5/24", "10.0.0.0/24", "::/0", "2001:db8::/32"},
+			expected: "[10.0.0.5/24 ::/0]",
+		},
+		{
+			prefixes: []string{},
+			expected: "[]",
+		},
+	}
+	for i, c := range testData {
+		prefixes := make([]netip.Prefix, len(c.prefixes))
+		for j, s := range c.prefixes {
+			prefixes[j] = netip.MustParsePrefix(s)
+		}
+		if r := DedupPrefixes(prefixes); fmt.Sprint(r) != c.expected {
+			t.Fatalf("case %d: result %v is different from expected %v", i, r, c.expected)
+		}
+	}
+	if r := DedupPrefixes([]netip.Prefix{{}, netip.MustParsePrefix("10.0.0.0/24")}); fmt.Sprint(r) != "[10.0.0.0/24]" {
+		t.Fatalf("invalid prefix is not dropped, %v", r)
+	}
+}
+
+type testPrefixGapCase struct {
+	a, b       string
+	expected   string
+	shouldFail bool
+}
+
+func TestPrefixGap(t *testing.T) {
+	testData := []testPrefixGapCase{
+		{a: "10.0.0.0/24", b: "10.0.1.0/24", expected: "0"},
+		{a: "10.0.1.0/24", b: "10.0.0.0/24", expected: "0"},
+		{a: "10.0.0.0/24", b: "10.0.2.0/24", expected: "256"},
+		{a: "10.0.0.0/25", b: "10.0.0.200/32", expected: "72"},
+		{a: "::/1", b: "ffff::/16", expected: "170135991163610696904058773219554885632"},
+		{a: "10.0.0.0/8", b: "10.0.1.0/24", shouldFail: true},
+		{a: "10.0.0.0/24", b: "::/0", shouldFail: true},
+	}
+	runTest := func(c testPrefixGapCase) error {
+		r, err := PrefixGap(netip.MustParsePrefix(c.a), netip.MustParsePrefix(c.b))
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testRangeToPrefixesCase struct {
+	start, end     string
+	expectedResult []string
+	shouldFail     bool
+}
+
+func TestRangeToPrefixes(t *testing.T) {
+	testData := []testRangeToPrefixesCase{
+		{
+			start:          "192.168.1.5",
+			end:            "192.168.1.20",
+			expectedResult: []string{"192.168.1.5/32", "192.168.1.6/31", "192.168.1.8/29", "192.168.1.16/30", "192.168.1.20/32"},
+		},
+		{
+			start:          "192.168.1.0",
+			end:            "192.168.1.255",
+			expectedResult: []string{"192.168.1.0/24"},
+		},
+		{
+			start:          "10.0.0.1",
+			end:            "10.0.0.1",
+			expectedResult: []string{"10.0.0.1/32"},
+		},
+		{
+			start:          "0.0.0.0",
+			end:            "255.255.255.255",
+			expectedResult: []string{"0.0.0.0/0"},
+		},
+		{
+			start:          "2001:dead::",
+			end:            "2001:dead::1:0",
+			expectedResult: []string{"2001:dead::/112", "2001:dead::1:0/128"},
+		},
+		{
+			start:          "::",
+			end:            "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+			expectedResult: []string{"::/0"},
+		},
+		{
+			start:      "192.168.1.20",
+			end:        "192.168.1.5",
+			shouldFail: true,
+		},
+		{
+			start:      "192.168.1.5",
+			end:        "2001:dead::1",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testRangeToPrefixesCase) error {
+		r, err := RangeToPrefixes(netip.MustParseAddr(c.start), netip.MustParseAddr(c.end))
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(r) != fmt.Sprint(c.expectedResult) {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedResult)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testPrefixRangeCase struct {
+	prefix      string
+	first, last string
+}
+
+func TestPrefixRange(t *testing.T) {
+	testData := []testPrefixRangeCase{
+		{
+			prefix: "192.168.1.100/24",
+			first:  "192.168.1.0",
+			last:   "192.168.1.255",
+		},
+		{
+			prefix: "0.0.0.0/0",
+			first:  "0.0.0.0",
+			last:   "255.255.255.255",
+		},
+		{
+			prefix: "10.0.0.3/31",
+			first:  "10.0.0.2",
+			last:   "10.0.0.3",
+		},
+		{
+			prefix: "10.0.0.3/32",
+			first:  "10.0.0.3",
+			last:   "10.0.0.3",
+		},
+		{
+			prefix: "10.0.0.0/13",
+			first:  "10.0.0.0",
+			last:   "10.7.255.255",
+		},
+		{
+			prefix: "::/0",
+			first:  "::",
+			last:   "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+		},
+		{
+			prefix: "2001:dead::1/127",
+			first:  "2001:dead::",
+			last:   "2001:dead::1",
+		},
+		{
+			prefix: "2001:dead::1/128",
+			first:  "2001:dead::1",
+			last:   "2001:dead::1",
+		},
+	}
+	for _, c := range testData {
+		first, last := PrefixRange(netip.MustParsePrefix(c.prefix))
+		if first.String() != c.first || last.String() != c.last {
+			t.Fatalf("range of %v is %v-%v, expected %v-%v", c.prefix, first, last, c.first, c.last)
+		}
+		if first.Compare(last) > 0 {
+			t.Fatalf("first %v is bigger than last %v", first, last)
+		}
+	}
+}
+
+func TestMasks(t *testing.T) {
+	testData := []struct {
+		prefix           string
+		expectedNetMask  string
+		expectedHostMask string
+	}{
+		{"192.168.1.0/24", "255.255.255.0", "0.0.0.255"},
+		{"10.0.0.0/9", "255.128.0.0", "0.127.255.255"},
+		{"0.0.0.0/0", "0.0.0.0", "255.255.255.255"},
+		{"10.0.0.1/32", "255.255.255.255", "0.0.0.0"},
+		{"2001:dead::/64", "ffff:ffff:ffff:ffff::", "::ffff:ffff:ffff:ffff"},
+	}
+	for i, c := range testData {
+		prefix := netip.MustParsePrefix(c.prefix)
+		if r := NetMask(prefix); r.String() != c.expectedNetMask {
+			t.Fatalf("case %d: NetMask %v is different from expected %v", i, r, c.expectedNetMask)
+		}
+		if r := HostMask(prefix); r.String() != c.expectedHostMask {
+			t.Fatalf("case %d: HostMask %v is different from expected %v", i, r, c.expectedHostMask)
+		}
+	}
+	if NetMask(netip.Prefix{}).IsValid() || HostMask(netip.Prefix{}).IsValid() {
+		t.Fatal("masks of invalid prefix should be invalid")
+	}
+}
+
+type testIncHostInPrefixCase struct {
+	prefix, addr    string
+	step            int64
+	expectedAddr    string
+	expectedWrapped bool
+	shouldFail      bool
+}
+
+func TestIncHostInPrefix(t *testing.T) {
+	testData := []testIncHostInPrefixCase{
+		{
+			prefix:       "192.168.1.0/24",
+			addr:         "192.168.1.10",
+			step:         5,
+			expectedAddr: "192.168.1.15",
+		},
+		{
+			prefix:          "fe80::/64",
+			addr:            "fe80::ffff:ffff:ffff:ffff%eth0",
+			step:            2,
+			expectedAddr:    "fe80::1%eth0",
+			expectedWrapped: true,
+		},
+		{
+			prefix:          "192.168.1.0/24",
+			addr:            "192.168.1.250",
+			step:            10,
+			expectedAddr:    "192.168.1.4",
+			expectedWrapped: true,
+		},
+		{
+			prefix:          "192.168.1.0/24",
+			addr:            "192.168.1.1",
+			step:            -2,
+			expectedAddr:    "192.168.1.255",
+			expectedWrapped: true,
+		},
+		{
+			prefix:          "192.168.1.0/24",
+			addr:            "192.168.1.1",
+			step:            512,
+			expectedAddr:    "192.168.1.1",
+			expectedWrapped: true,
+		},
+		{
+			prefix:          "2001:dead::/127",
+			addr:            "2001:dead::1",
+			step:            1,
+			expectedAddr:    "2001:dead::",
+			expectedWrapped: true,
+		},
+		{
+			prefix:       "255.255.255.0/24",
+			addr:         "255.255.255.254",
+			step:         1,
+			expectedAddr: "255.255.255.255",
+		},
+		{
+			prefix:     "192.168.1.0/24",
+			addr:       "192.168.2.1",
+			step:       1,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testIncHostInPrefixCase) error {
+		r, wrapped, err := IncHostInPrefix(netip.MustParsePrefix(c.prefix), netip.MustParseAddr(c.addr), big.NewInt(c.step))
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expectedAddr || wrapped != c.expectedWrapped {
+			return fmt.Errorf("result %v/%v is different from expected %v/%v", r, wrapped, c.expectedAddr, c.expectedWrapped)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testNetworkBroadcastCase struct {
+	prefix, addr      string
+	expectedNetwork   bool
+	expectedBroadcast bool
+}
+
+func TestIsNetworkBroadcastAddr(t *testing.T) {
+	testData := []testNetworkBroadcastCase{
+		{
+			prefix:          "192.168.1.0/24",
+			addr:            "192.168.1.0",
+			expectedNetwork: true,
+		},
+		{
+			prefix:            "192.168.1.0/24",
+			addr:              "192.168.1.255",
+			expectedBroadcast: true,
+		},
+		{
+			prefix: "192.168.1.0/24",
+			addr:   "192.168.1.1",
+		},
+		{
+			prefix: "192.168.1.0/24",
+			addr:   "192.168.2.0",
+		},
+		{
+			prefix:            "10.0.0.0/30",
+			addr:              "10.0.0.3",
+			expectedBroadcast: true,
+		},
+		{
+			prefix: "10.0.0.0/31",
+			addr:   "10.0.0.0",
+		},
+		{
+			prefix: "10.0.0.0/31",
+			addr:   "10.0.0.1",
+		},
+		{
+			prefix: "10.0.0.0/32",
+			addr:   "10.0.0.0",
+		},
+		{
+			prefix:          "2001:dead::/64",
+			addr:            "2001:dead::",
+			expectedNetwork: true,
+		},
+		{
+			prefix:          "fe80::/64",
+			addr:            "fe80::%eth0",
+			expectedNetwork: true,
+		},
+		{
+			prefix: "2001:dead::/64",
+			addr:   "2001:dead::ffff:ffff:ffff:ffff",
+		},
+		{
+			prefix: "2001:dead::/127",
+			addr:   "2001:dead::",
+		},
+	}
+	for i, c := range testData {
+		prefix := netip.MustParsePrefix(c.prefix)
+		addr := netip.MustParseAddr(c.addr)
+		if r := IsNetworkAddr(prefix, addr); r != c.expectedNetwork {
+			t.Fatalf("case %d: IsNetworkAddr returned %v, expected %v", i, r, c.expectedNetwork)
+		}
+		if r := IsBroadcastAddr(prefix, addr); r != c.expectedBroadcast {
+			t.Fatalf("case %d: IsBroadcastAddr returned %v, expected %v", i, r, c.expectedBroadcast)
+		}
+	}
+}
+
+func TestIsHostZero(t *testing.T) {
+	testData := []struct {
+		prefix   string
+		addr     string
+		expected bool
+	}{
+		{"10.0.0.0/24", "10.0.0.0", true},
+		{"10.0.0.0/24", "10.0.0.1", false},
+		{"10.0.0.0/24", "10.0.1.0", false},
+		{"10.0.0.0/31", "10.0.0.0", true},
+		{"10.0.0.1/32", "10.0.0.1", true},
+		{"2001:dead::/64", "2001:dead::", true},
+		{"2001:dead::/64", "2001:dead::%eth0", true},
+		{"2001:dead::/64", "2001:dead::1", false},
+	}
+	for i, c := range testData {
+		if r := IsHostZero(netip.MustParsePrefix(c.prefix), netip.MustParseAddr(c.addr)); r != c.expected {
+			t.Fatalf("case %d: IsHostZero returned %v, expected %v", i, r, c.expected)
+		}
+	}
+	if !HostBitsZero(netip.MustParseAddr("10.1.0.0"), 16) || HostBitsZero(netip.MustParseAddr("10.1.0.0"), 15) {
+		t.Fatal("HostBitsZero returned wrong result for 10.1.0.0")
+	}
+	if HostBitsZero(netip.MustParseAddr("10.1.0.0"), 33) || HostBitsZero(netip.Addr{}, 0) {
+		t.Fatal("HostBitsZero should return false for invalid input")
+	}
+}
+
+type testAlignCase struct {
+	addr         string
+	bits         int
+	expectedDown string
+	expectedUp   string
+	shouldFail   bool
+}
+
+func TestAlign(t *testing.T) {
+	testData := []testAlignCase{
+		{addr: "10.0.0.5", bits: 24, expectedDown: "10.0.0.0", expectedUp: "10.0.1.0"},
+		{addr: "10.0.1.0", bits: 24, expectedDown: "10.0.1.0", expectedUp: "10.0.1.0"},
+		{addr: "10.0.0.5", bits: 32, expectedDown: "10.0.0.5", expectedUp: "10.0.0.5"},
+		{addr: "10.0.0.5", bits: 0, expectedDown: "0.0.0.0", shouldFail: true},
+		{addr: "2001:dead::1%eth0", bits: 64, expectedDown: "2001:dead::", expectedUp: "2001:dead:0:1::"},
+		{addr: "255.255.255.1", bits: 24, expectedDown: "255.255.255.0", shouldFail: true},
+		{addr: "10.0.0.5", bits: 33, shouldFail: true},
+		{addr: "10.0.0.5", bits: -1, shouldFail: true},
+	}
+	runTest := func(c testAlignCase) error {
+		addr := netip.MustParseAddr(c.addr)
+		down, err := AlignDown(addr, c.bits)
+		if err != nil {
+			return err
+		}
+		if down.String() != c.expectedDown {
+			return fmt.Errorf("AlignDown result %v is different from expected %v", down, c.expectedDown)
+		}
+		up, err := AlignUp(addr, c.bits)
+		if err != nil {
+			return err
+		}
+		if up.String() != c.expectedUp {
+			return fmt.Errorf("AlignUp result %v is different from expected %v", up, c.expectedUp)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testPrefixComplementCase struct {
+	parent, child string
+	expected      string
+	shouldFail    bool
+}
+
+func TestPrefixComplement(t *testing.T) {
+	testData := []testPrefixComplementCase{
+		{parent: "10.0.0.0/24", child: "10.0.0.0/26", expected: "[10.0.0.64/26 10.0.0.128/25]"},
+		{parent: "10.0.0.0/24", child: "10.0.0.64/26", expected: "[10.0.0.0/26 10.0.0.128/25]"},
+		{parent: "10.0.0.0/24", child: "10.0.0.5/32", expected: "[10.0.0.0/30 10.0.0.4/32 10.0.0.6/31 10.0.0.8/29 10.0.0.16/28 10.0.0.32/27 10.0.0.64/26 10.0.0.128/25]"},
+		{parent: "10.0.0.0/24", child: "10.0.0.0/24", expected: "[]"},
+		{parent: "2001:dead::/32", child: "2001:dead:8000::/33", expected: "[2001:dead::/33]"},
+		{parent: "10.0.0.0/24", child: "10.0.0.0/23", shouldFail: true},
+		{parent: "10.0.0.0/24", child: "10.0.1.0/26", shouldFail: true},
+		{parent: "10.0.0.0/24", child: "::/64", shouldFail: true},
+	}
+	runTest := func(c testPrefixComplementCase) error {
+		r, err := PrefixComplement(netip.MustParsePrefix(c.parent), netip.MustParsePrefix(c.child))
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(r) != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testSameSubnetCase struct {
+	a, b       string
+	bits       int
+	expected   bool
+	shouldFail bool
+}
+
+func TestSameSubnet(t *testing.T) {
+	testData := []testSameSubnetCase{
+		{a: "10.0.0.5", b: "10.0.0.200", bits: 24, expected: true},
+		{a: "10.0.0.5", b: "10.0.0.200", bits: 25, expected: false},
+		{a: "10.0.0.5", b: "10.0.0.5", bits: 32, expected: true},
+		{a: "10.0.0.5", b: "192.168.0.5", bits: 0, expected: true},
+		{a: "fe80::1%eth0", b: "fe80::2%eth1", bits: 64, expected: true},
+		{a: "10.0.0.5", b: "::a00:5", bits: 24, shouldFail: true},
+		{a: "10.0.0.5", b: "10.0.0.200", bits: 33, shouldFail: true},
+	}
+	runTest := func(c testSameSubnetCase) error {
+		r, err := SameSubnet(netip.MustParseAddr(c.a), netip.MustParseAddr(c.b), c.bits)
+		if err != nil {
+			return err
+		}
+		if r != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testSplitAddrCase struct {
+	addr            string
+	prefixBits      int
+	expectedNetwork string
+	expectedIndex   string
+	shouldFail      bool
+}
+
+func TestSplitAddr(t *testing.T) {
+	testData := []testSplitAddrCase{
+		{addr: "10.1.2.3", prefixBits: 24, expectedNetwork: "10.1.2.0", expectedIndex: "3"},
+		{addr: "10.1.2.3", prefixBits: 16, expectedNetwork: "10.1.0.0", expectedIndex: "515"},
+		{addr: "10.1.2.3", prefixBits: 32, expectedNetwork: "10.1.2.3", expectedIndex: "0"},
+		{addr: "2001:dead::1:2%eth0", prefixBits: 64, expectedNetwork: "2001:dead::", expectedIndex: "65538"},
+		{addr: "10.1.2.3", prefixBits: 33, shouldFail: true},
+	}
+	runTest := func(c testSplitAddrCase) error {
+		network, index, err := SplitAddr(netip.MustParseAddr(c.addr), c.prefixBits)
+		if err != nil {
+			return err
+		}
+		if network.String() != c.expectedNetwork || index.String() != c.expectedIndex {
+			return fmt.Errorf("result %v, %v is different from expected %v, %v", network, index, c.expectedNetwork, c.expectedIndex)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testRelocateHostCase struct {
+	addr         string
+	fromBits     int
+	toPrefix     string
+	expectedAddr string
+	shouldFail   bool
+}
+
+func TestRelocateHost(t *testing.T) {
+	testData := []testRelocateHostCase{
+		{addr: "10.0.0.5", fromBits: 24, toPrefix: "192.168.1.0/24", expectedAddr: "192.168.1.5"},
+		{addr: "10.0.1.5", fromBits: 23, toPrefix: "192.168.1.0/16", expectedAddr: "192.168.1.5"},
+		{addr: "10.0.0.5", fromBits: 24, toPrefix: "192.168.1.0/29", expectedAddr: "192.168.1.5"},
+		{addr: "2001:dead::1:5", fromBits: 64, toPrefix: "2001:beef:0:1::/64", expectedAddr: "2001:beef:0:1::1:5"},
+		{addr: "::ffff:10.0.0.5", fromBits: 120, toPrefix: "::ffff:192.168.1.0/120", expectedAddr: "::ffff:192.168.1.5"},
+		{addr: "10.0.0.9", fromBits: 24, toPrefix: "192.168.1.0/29", shouldFail: true},
+		{addr: "10.0.0.5", fromBits: 24, toPrefix: "2001:beef::/64", shouldFail: true},
+		{addr: "10.0.0.5", fromBits: 33, toPrefix: "192.168.1.0/24", shouldFail: true},
+	}
+	runTest := func(c testRelocateHostCase) error {
+		r, err := RelocateHost(netip.MustParseAddr(c.addr), c.fromBits, netip.MustParsePrefix(c.toPrefix))
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expectedAddr {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedAddr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestDescribePrefix(t *testing.T) {
+	testData := []struct {
+		prefix   string
+		expected string
+	}{
+		{"192.168.1.0/24", "192.168.1.0/24 (256 addresses, 254 usable, 192.168.1.1-192.168.1.254)"},
+		{"192.168.1.5/30", "192.168.1.4/30 (4 addresses, 2 usable, 192.168.1.5-192.168.1.6)"},
+		{"192.168.1.4/31", "192.168.1.4/31 (2 addresses, 192.168.1.4-192.168.1.5)"},
+		{"192.168.1.4/32", "192.168.1.4/32 (1 address, 192.168.1.4)"},
+		{"2001:db8::/126", "2001:db8::/126 (4 addresses, 2001:db8::-2001:db8::3)"},
+		{"2001:db8::/64", "2001:db8::/64 (18446744073709551616 addresses, 2001:db8::-2001:db8::ffff:ffff:ffff:ffff)"},
+	}
+	for i, c := range testData {
+		if r := DescribePrefix(netip.MustParsePrefix(c.prefix)); r != c.expected {
+			t.Fatalf("case %d: result %q is different from expected %q", i, r, c.expected)
+		}
+	}
+	if r := DescribePrefix(netip.Prefix{}); r != "invalid Prefix" {
+		t.Fatalf("result %q of invalid prefix is different from expected", r)
+	}
+}
+
+type testPrefixHostCase struct {
+	text         string
+	expectedAddr string
+	shouldFail   bool
+}
+
+func TestPrefixHost(t *testing.T) {
+	testData := []testPrefixHostCase{
+		{
+			text:         "10.0.0.0/24#5",
+			expectedAddr: "10.0.0.5",
+		},
+		{
+			text:         "2001:dead::/64#65536",
+			expectedAddr: "2001:dead::1:0",
+		},
+		{
+			text:         "::ffff:10.0.0.0/120#5",
+			expectedAddr: "::ffff:10.0.0.5",
+		},
+		{
+			text:       "10.0.0.0/24#256",
+			shouldFail: true,
+		},
+		{
+			text:       "10.0.0.0/24#-1",
+			shouldFail: true,
+		},
+		{
+			text:       "10.0.0.0/24",
+			shouldFail: true,
+		},
+		{
+			text:       "10.0.0.0/33#1",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testPrefixHostCase) error {
+		var ph PrefixHost
+		if err := ph.UnmarshalText([]byte(c.text)); err != nil {
+			return err
+		}
+		addr, err := ph.Addr()
+		if err != nil {
+			return err
+		}
+		if addr.String() != c.expectedAddr {
+			return fmt.Errorf("result %v is different from expected %v", addr, c.expectedAddr)
+		}
+		b, err := ph.MarshalText()
+		if err != nil {
+			return err
+		}
+		if string(b) != c.text {
+			return fmt.Errorf("marshaled result %v is different from original %v", string(b), c.text)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestPrefixHostInvalidPrefix(t *testing.T) {
+	if _, err := (PrefixHost{Index: big.NewInt(0)}).Addr(); err == nil {
+		t.Fatal("zero value prefix should fail")
+	}
+}
+
+type testPrefixLenForCase struct {
+	n                string
+	ipv4             bool
+	expectedHostBits int
+	expectedLen      int
+	shouldFail       bool
+}
+
+func TestPrefixLenFor(t *testing.T) {
+	testData := []testPrefixLenForCase{
+		{
+			n:                "0",
+			ipv4:             true,
+			expectedHostBits: 0,
+			expectedLen:      32,
+		},
+		{
+			n:                "1",
+			ipv4:             true,
+			expectedHostBits: 0,
+			expectedLen:      32,
+		},
+		{
+			n:                "2",
+			ipv4:             true,
+			expectedHostBits: 1,
+			expectedLen:      31,
+		},
+		{
+			n:                "254",
+			ipv4:             true,
+			expectedHostBits: 8,
+			expectedLen:      24,
+		},
+		{
+			n:                "256",
+			ipv4:             true,
+			expectedHostBits: 8,
+			expectedLen:      24,
+		},
+		{
+			n:                "257",
+			ipv4:             true,
+			expectedHostBits: 9,
+			expectedLen:      23,
+		},
+		{
+			n:                "4294967296",
+			ipv4:             true,
+			expectedHostBits: 32,
+			expectedLen:      0,
+		},
+		{
+			n:                "4294967297",
+			ipv4:             true,
+			expectedHostBits: 33,
+			shouldFail:       true,
+		},
+		{
+			n:                "4294967297",
+			expectedHostBits: 33,
+			expectedLen:      95,
+		},
+		{
+			n:                "340282366920938463463374607431768211456",
+			expectedHostBits: 128,
+			expectedLen:      0,
+		},
+		{
+			n:                "340282366920938463463374607431768211457",
+			expectedHostBits: 129,
+			shouldFail:       true,
+		},
+		{
+			n:          "-1",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testPrefixLenForCase) error {
+		n, _ := new(big.Int).SetString(c.n, 10)
+		if n.Sign() >= 0 {
+			if hb := HostBitsFor(n); hb != c.expectedHostBits {
+				return fmt.Errorf("host bits for %v is %d, expected %d", n, hb, c.expectedHostBits)
+			}
+		}
+		l, err := PrefixLenFor(n, c.ipv4)
+		if err != nil {
+			return err
+		}
+		if l != c.expectedLen {
+			return fmt.Errorf("prefix length for %v is %d, expected %d", n, l, c.expectedLen)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testExactPrefixLenCase struct {
+	n           string
+	ipv4        bool
+	expectedLen int
+	shouldFail  bool
+}
+
+func TestExactPrefixLen(t *testing.T) {
+	testData := []testExactPrefixLenCase{
+		{n: "256", ipv4: true, expectedLen: 24},
+		{n: "1", ipv4: true, expectedLen: 32},
+		{n: "2", ipv4: true, expectedLen: 31},
+		{n: "4294967296", ipv4: true, expectedLen: 0},
+		{n: "18446744073709551616", expectedLen: 64},
+		{n: "340282366920938463463374607431768211456", expectedLen: 0},
+		{n: "300", ipv4: true, shouldFail: true},
+		{n: "255", ipv4: true, shouldFail: true},
+		{n: "8589934592", ipv4: true, shouldFail: true},
+		{n: "0", ipv4: true, shouldFail: true},
+		{n: "-256", ipv4: true, shouldFail: true},
+	}
+	runTest := func(c testExactPrefixLenCase) error {
+		n, _ := new(big.Int).SetString(c.n, 10)
+		l, err := ExactPrefixLen(n, c.ipv4)
+		if err != nil {
+			return err
+		}
+		if l != c.expectedLen {
+			return fmt.Errorf("prefix length for %v is %d, expected %d", n, l, c.expectedLen)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testFindGapsCase struct {
+	prefixes       []string
+	within         string
+	expectedResult []string
+	shouldFail     bool
+}
+
+func TestFindGaps(t *testing.T) {
+	testData := []testFindGapsCase{
+		{
+			prefixes:       []string{"192.168.1.0/26", "192.168.1.128/26"},
+			within:         "192.168.1.0/24",
+			expectedResult: []string{"192.168.1.64/26", "192.168.1.192/26"},
+		},
+		{
+			prefixes:       []string{},
+			within:         "192.168.1.0/24",
+			expectedResult: []string{"192.168.1.0/24"},
+		},
+		{
+			prefixes:       []string{"192.168.0.0/16"},
+			within:         "192.168.1.0/24",
+			expectedResult: []string{},
+		},
+		{
+			prefixes:       []string{"192.168.1.0/25", "192.168.1.0/26", "192.168.1.64/27", "10.0.0.0/8"},
+			within:         "192.168.1.0/24",
+			expectedResult: []string{"192.168.1.128/25"},
+		},
+		{
+			prefixes:       []string{"192.168.1.5/32"},
+			within:         "192.168.1.0/29",
+			expectedResult: []string{"192.168.1.0/30", "192.168.1.4/32", "192.168.1.6/31"},
+		},
+		{
+			prefixes:       []string{"2001:dead:beef:1::/64"},
+			within:         "2001:dead:beef::/62",
+			expectedResult: []string{"2001:dead:beef::/64", "2001:dead:beef:2::/63"},
+		},
+		{
+			prefixes:   []string{"2001:dead:beef:1::/64"},
+			within:     "192.168.1.0/24",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testFindGapsCase) error {
+		prefixes := []netip.Prefix{}
+		for _, p := range c.prefixes {
+			prefixes = append(prefixes, netip.MustParsePrefix(p))
+		}
+		r, err := FindGaps(prefixes, netip.MustParsePrefix(c.within))
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(r) != fmt.Sprint(c.expectedResult) {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedResult)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testNextPrevPrefixAddrCase struct {
+	prefix             string
+	expectedNext       string
+	expectedPrev       string
+	nextFail, prevFail bool
+}
+
+func TestNextPrevPrefixAddr(t *testing.T) {
+	testData := []testNextPrevPrefixAddrCase{
+		{
+			prefix:       "192.168.1.0/24",
+			expectedNext: "192.168.2.0",
+			expectedPrev: "192.168.0.255",
+		},
+		{
+			prefix:       "2001:dead::/64",
+			expectedNext: "2001:dead:0:1::",
+			expectedPrev: "2001:deac:ffff:ffff:ffff:ffff:ffff:ffff",
+		},
+		{
+			prefix:       "0.0.0.0/8",
+			expectedNext: "1.0.0.0",
+			prevFail:     true,
+		},
+		{
+			prefix:       "255.255.255.0/24",
+			expectedPrev: "255.255.254.255",
+			nextFail:     true,
+		},
+		{
+			prefix:   "::/0",
+			nextFail: true,
+			prevFail: true,
+		},
+	}
+	for i, c := range testData {
+		prefix := netip.MustParsePrefix(c.prefix)
+		next, err := NextPrefixAddr(prefix)
+		if (err != nil) != c.nextFail {
+			t.Fatalf("case %d: unexpected NextPrefixAddr error %v", i, err)
+		}
+		if err == nil && next.String() != c.expectedNext {
+			t.Fatalf("case %d: NextPrefixAddr returned %v, expected %v", i, next, c.expectedNext)
+		}
+		prev, err := PrevPrefixAddr(prefix)
+		if (err != nil) != c.prevFail {
+			t.Fatalf("case %d: unexpected PrevPrefixAddr error %v", i, err)
+		}
+		if err == nil && prev.String() != c.expectedPrev {
+			t.Fatalf("case %d: PrevPrefixAddr returned %v, expected %v", i, prev, c.expectedPrev)
+		}
+	}
+}
+
+type testParsePrefixCase struct {
+	prefixStr   string
+	expected    string
+	strictFail  bool
+	lenientFail bool
+}
+
+func TestParsePrefixCanonicalStrict(t *testing.T) {
+	testData := []testParsePrefixCase{
+		{
+			prefixStr: "192.168.1.0/24",
+			expected:  "192.168.1.0/24",
+		},
+		{
+			prefixStr:  "192.168.1.5/24",
+			expected:   "192.168.1.0/24",
+			strictFail: true,
+		},
+		{
+			prefixStr:  "2001:dead::1/64",
+			expected:   "2001:dead::/64",
+			strictFail: true,
+		},
+		{
+			prefixStr: "2001:dead::/64",
+			expected:  "2001:dead::/64",
+		},
+		{
+			prefixStr:   "192.168.1.5",
+			strictFail:  true,
+			lenientFail: true,
+		},
+	}
+	for i, c := range testData {
+		p, err := ParsePrefixCanonical(c.prefixStr)
+		if (err != nil) != c.lenientFail {
+			t.Fatalf("case %d: unexpected ParsePrefixCanonical error %v", i, err)
+		}
+		if err == nil && p.String() != c.expected {
+			t.Fatalf("case %d: ParsePrefixCanonical returned %v, expected %v", i, p, c.expected)
+		}
+		p, err = ParsePrefixStrict(c.prefixStr)
+		if (err != nil) != c.strictFail {
+			t.Fatalf("case %d: unexpected ParsePrefixStrict error %v", i, err)
+		}
+		if err == nil && p.String() != c.expected {
+			t.Fatalf("case %d: ParsePrefixStrict returned %v, expected %v", i, p, c.expected)
+		}
+	}
+}
+
+type testParseAddrOrPrefixCase struct {
+	s                string
+	expectedAddr     string
+	expectedPrefix   string
+	expectedIsPrefix bool
+	shouldFail       bool
+}
+
+func TestParseAddrOrPrefix(t *testing.T) {
+	testData := []testParseAddrOrPrefixCase{
+		{s: "10.0.0.1", expectedAddr: "10.0.0.1", expectedPrefix: "10.0.0.1/32"},
+		{s: "10.0.0.0/24", expectedAddr: "10.0.0.0", expectedPrefix: "10.0.0.0/24", expectedIsPrefix: true},
+		{s: "10.0.0.5/24", expectedAddr: "10.0.0.5", expectedPrefix: "10.0.0.5/24", expectedIsPrefix: true},
+		{s: "2001:db8::1%eth0", expectedAddr: "2001:db8::1%eth0", expectedPrefix: "2001:db8::1/128"},
+		{s: "2001:db8::/32", expectedAddr: "2001:db8::", expectedPrefix: "2001:db8::/32", expectedIsPrefix: true},
+		{s: "10.0.0.256", shouldFail: true},
+		{s: "10.0.0.0/33", shouldFail: true},
+		{s: "", shouldFail: true},
+	}
+	runTest := func(c testParseAddrOrPrefixCase) error {
+		addr, prefix, isPrefix, err := ParseAddrOrPrefix(c.s)
+		if err != nil {
+			return err
+		}
+		if addr.String() != c.expectedAddr || prefix.String() != c.expectedPrefix || isPrefix != c.expectedIsPrefix {
+			return fmt.Errorf("result %v, %v, %v is different from expected %v, %v, %v",
+				addr, prefix, isPrefix, c.expectedAddr, c.expectedPrefix, c.expectedIsPrefix)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestRequirePrefixLen(t *testing.T) {
+	testData := []struct {
+		prefix     string
+		allowed    []int
+		shouldFail bool
+	}{
+		{"2001:db8::/64", []int{64}, false},
+		{"2001:db8::/56", []int{64}, true},
+		{"10.0.0.0/31", []int{31, 127}, false},
+		{"2001:db8::/127", []int{31, 127}, false},
+		{"10.0.0.0/30", []int{31, 127}, true},
+		{"10.0.0.0/24", nil, true},
+	}
+	for i, c := range testData {
+		err := RequirePrefixLen(netip.MustParsePrefix(c.prefix), c.allowed...)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if err := RequirePrefixLen(netip.Prefix{}, 0); err == nil {
+		t.Fatal("invalid prefix should fail")
+	}
+}
+
+func TestIsValidHostSubnet(t *testing.T) {
+	testData := []struct {
+		prefix   string
+		expected bool
+	}{
+		//IPv4 subnet with network and broadcast address
+		{"10.0.0.0/24", true},
+		{"10.0.0.0/30", true},
+		//point-to-point
+		{"10.0.0.0/31", true},
+		{"2001:db8::/127", true},
+		{"10.0.0.1/32", false},
+		//SLAAC
+		{"2001:db8::/64", true},
+		{"2001:db8::/56", false},
+		{"2001:db8::/126", false},
+		{"2001:db8::1/128", false},
+	}
+	for i, c := range testData {
+		if r := IsValidHostSubnet(netip.MustParsePrefix(c.prefix)); r != c.expected {
+			t.Fatalf("case %d: result %v of %v is different from expected %v", i, r, c.prefix, c.expected)
+		}
+	}
+	if IsValidHostSubnet(netip.Prefix{}) {
+		t.Fatal("invalid prefix should not be a valid host subnet")
+	}
+}
+
+type testSubnetCountCase struct {
+	parent     string
+	subBits    int
+	expected   string
+	shouldFail bool
+}
+
+func TestSubnetCount(t *testing.T) {
+	testData := []testSubnetCountCase{
+		{parent: "10.0.0.0/16", subBits: 24, expected: "256"},
+		{parent: "10.0.0.0/16", subBits: 16, expected: "1"},
+		{parent: "0.0.0.0/0", subBits: 32, expected: "4294967296"},
+		{parent: "::/0", subBits: 128, expected: "340282366920938463463374607431768211456"},
+		{parent: "2001:dead::/48", subBits: 64, expected: "65536"},
+		{parent: "10.0.0.0/16", subBits: 15, shouldFail: true},
+		{parent: "10.0.0.0/16", subBits: 33, shouldFail: true},
+	}
+	runTest := func(c testSubnetCountCase) error {
+		r, err := SubnetCount(netip.MustParsePrefix(c.parent), c.subBits)
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testNthSubnetCase struct {
+	parent         string
+	newBits, index int
+	expected       string
+	shouldFail     bool
+}
+
+func TestNthSubnet(t *testing.T) {
+	testData := []testNthSubnetCase{
+		{
+			parent:   "192.168.1.0/24",
+			newBits:  26,
+			index:    0,
+			expected: "192.168.1.0/26",
+		},
+		{
+			parent:   "192.168.1.0/24",
+			newBits:  26,
+			index:    3,
+			expected: "192.168.1.192/26",
+		},
+		{
+			parent:   "192.168.1.0/24",
+			newBits:  24,
+			index:    0,
+			expected: "192.168.1.0/24",
+		},
+		{
+			parent:   "2001:dead:beef::/48",
+			newBits:  64,
+			index:    65535,
+			expected: "2001:dead:beef:ffff::/64",
+		},
+		{
+			parent:     "192.168.1.0/24",
+			newBits:    26,
+			index:      4,
+			shouldFail: true,
+		},
+		{
+			parent:     "192.168.1.0/24",
+			newBits:    26,
+			index:      -1,
+			shouldFail: true,
+		},
+		{
+			parent:     "192.168.1.0/24",
+			newBits:    23,
+			index:      0,
+			shouldFail: true,
+		},
+		{
+			parent:     "192.168.1.0/24",
+			newBits:    33,
+			index:      0,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testNthSubnetCase) error {
+		r, err := NthSubnet(netip.MustParsePrefix(c.parent), c.newBits, c.index)
+		if err != nil {
+			return err
+		}
+		if r.String() != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testSupernetCase struct {
+	prefix           string
+	expectedSupernet string
+	expectedSibling  string
+	shouldFail       bool
+}
+
+func TestSupernet(t *testing.T) {
+	testData := []testSupernetCase{
+		{prefix: "10.0.1.0/24", expectedSupernet: "10.0.0.0/23", expectedSibling: "10.0.0.0/24"},
+		{prefix: "10.0.0.0/24", expectedSupernet: "10.0.0.0/23", expectedSibling: "10.0.1.0/24"},
+		{prefix: "10.0.1.5/24", expectedSupernet: "10.0.0.0/23", expectedSibling: "10.0.0.0/24"},
+		{prefix: "10.0.0.1/32", expectedSupernet: "10.0.0.0/31", expectedSibling: "10.0.0.0/32"},
+		{prefix: "128.0.0.0/1", expectedSupernet: "0.0.0.0/0", expectedSibling: "0.0.0.0/1"},
+		{prefix: "2001:dead:beef::/48", expectedSupernet: "2001:dead:beee::/47", expectedSibling: "2001:dead:beee::/48"},
+		{prefix: "0.0.0.0/0", shouldFail: true},
+		{prefix: "::/0", shouldFail: true},
+	}
+	runTest := func(c testSupernetCase) error {
+		prefix := netip.MustParsePrefix(c.prefix)
+		supernet, err := Supernet(prefix)
+		if err != nil {
+			return err
+		}
+		if supernet.String() != c.expectedSupernet {
+			return fmt.Errorf("supernet %v is different from expected %v", supernet, c.expectedSupernet)
+		}
+		sibling, err := SiblingPrefix(prefix)
+		if err != nil {
+			return err
+		}
+		if sibling.String() != c.expectedSibling {
+			return fmt.Errorf("sibling %v is different from expected %v", sibling, c.expectedSibling)
+		}
+		//prefix and its sibling aggregate into the supernet
+		var set AddrSet
+		set.Add(prefix)
+		set.Add(sibling)
+		if r := set.Prefixes(); len(r) != 1 || r[0] != supernet {
+			return fmt.Errorf("%v and %v aggregate into %v, not %v", prefix, sibling, r, supernet)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestSubPrefixSeq(t *testing.T) {
+	r := []string{}
+	for p, err := range SubPrefixSeq(netip.MustParsePrefix("2001:dead:beef::/48"), 64) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = append(r, p.String())
+		if len(r) == 3 {
+			break
+		}
+	}
+	expected := "[2001:dead:beef::/64 2001:dead:beef:1::/64 2001:dead:beef:2::/64]"
+	if fmt.Sprint(r) != expected {
+		t.Fatalf("result %v is different from expected %v", r, expected)
+	}
+	r = []string{}
+	for p, err := range SubPrefixSeq(netip.MustParsePrefix("192.168.1.100/24"), 26) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = append(r, p.String())
+	}
+	expected = "[192.168.1.0/26 192.168.1.64/26 192.168.1.128/26 192.168.1.192/26]"
+	if fmt.Sprint(r) != expected {
+		t.Fatalf("result %v is different from expected %v", r, expected)
+	}
+	r = []string{}
+	for p, err := range SubPrefixSeq(netip.MustParsePrefix("255.255.255.255/32"), 32) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = append(r, p.String())
+	}
+	if fmt.Sprint(r) != "[255.255.255.255/32]" {
+		t.Fatalf("result %v is different from expected [255.255.255.255/32]", r)
+	}
+	for _, subBits := range []int{23, 33} {
+		n := 0
+		for _, err := range SubPrefixSeq(netip.MustParsePrefix("192.168.1.0/24"), subBits) {
+			if err == nil {
+				t.Fatalf("sub-prefix length %d should fail", subBits)
+			}
+			n++
+		}
+		if n != 1 {
+			t.Fatalf("expect a single error for sub-prefix length %d, got %d", subBits, n)
+		}
+	}
+}
+
+func TestEnclosingPrefixes(t *testing.T) {
+	for _, s := range []string{"192.168.1.1", "2001:dead::1%eth0", "::ffff:1.2.3.4"} {
+		addr := netip.MustParseAddr(s)
+		r := EnclosingPrefixes(addr)
+		if len(r) != addr.BitLen()+1 {
+			t.Fatalf("got %d prefixes for %v, expect %d", len(r), addr, addr.BitLen()+1)
+		}
+		for i, p := range r {
+			if p.Bits() != addr.BitLen()-i {
+				t.Fatalf("prefix %d %v of %v has wrong prefix length", i, p, addr)
+			}
+			if !p.Contains(addr.WithZone("")) || p != p.Masked() {
+				t.Fatalf("prefix %d %v is not a masked prefix containing %v", i, p, addr)
+			}
+		}
+	}
+	r := EnclosingPrefixes(netip.MustParseAddr("192.168.1.1"))
+	if r[0].String() != "192.168.1.1/32" || r[8].String() != "192.168.1.0/24" || r[32].String() != "0.0.0.0/0" {
+		t.Fatalf("unexpected result %v", r)
+	}
+	if r := EnclosingPrefixes(netip.Addr{}); r != nil {
+		t.Fatalf("result %v for invalid address is not nil", r)
+	}
+}
+
+type testSampleAddrsCase struct {
+	prefix     string
+	n          int
+	expected   string
+	shouldFail bool
+}
+
+func TestSampleAddrs(t *testing.T) {
+	testData := []testSampleAddrsCase{
+		{prefix: "10.0.0.0/24", n: 2, expected: "[10.0.0.0 10.0.0.255]"},
+		{prefix: "10.0.0.0/24", n: 4, expected: "[10.0.0.0 10.0.0.85 10.0.0.170 10.0.0.255]"},
+		{prefix: "10.0.0.0/24", n: 1, expected: "[10.0.0.0]"},
+		{prefix: "10.0.0.0/24", n: 0, expected: "[]"},
+		{prefix: "10.0.0.0/30", n: 10, expected: "[10.0.0.0 10.0.0.1 10.0.0.2 10.0.0.3]"},
+		{prefix: "10.0.0.0/30", n: 4, expected: "[10.0.0.0 10.0.0.1 10.0.0.2 10.0.0.3]"},
+		{prefix: "::/0", n: 3, expected: "[:: 7fff:ffff:ffff:ffff:ffff:ffff:ffff:ffff ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff]"},
+		{prefix: "10.0.0.0/24", n: -1, shouldFail: true},
+	}
+	runTest := func(c testSampleAddrsCase) error {
+		r, err := SampleAddrs(netip.MustParsePrefix(c.prefix), c.n)
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(r) != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestAddrFromKey(t *testing.T) {
+	for _, s := range []string{"10.0.0.0/24", "10.0.0.5/30", "10.0.0.1/32", "2001:dead::/64", "::/0", "::ffff:10.0.0.0/120"} {
+		prefix := netip.MustParsePrefix(s)
+		seen := map[netip.Addr]bool{}
+		for _, key := range []string{"tenant-a", "tenant-b", "tenant-c", ""} {
+			a1, err := AddrFromKey(prefix, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a2, err := AddrFromKey(prefix, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a1 != a2 {
+				t.Fatalf("key %q results in different addresses %v and %v", key, a1, a2)
+			}
+			if !prefix.Contains(a1) || a1.Is4In6() != prefix.Addr().Is4In6() {
+				t.Fatalf("%v of key %q is not in %v", a1, key, prefix)
+			}
+			seen[a1] = true
+		}
+		if prefix.Bits() <= 24 && len(seen) != 4 {
+			t.Fatalf("expect 4 different addresses in %v, got %v", prefix, seen)
+		}
+	}
+	//FNV-1a 64 of "tenant-a" is 0xc2ef8128e3eb9efb
+	r, err := AddrFromKey(netip.MustParsePrefix("2001:dead::/64"), "tenant-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "2001:dead::c2ef:8128:e3eb:9efb" {
+		t.Fatalf("result %v is different from expected 2001:dead::c2ef:8128:e3eb:9efb", r)
+	}
+	if _, err := AddrFromKey(netip.Prefix{}, "x"); err == nil {
+		t.Fatal("invalid prefix should fail")
+	}
+}
+
+type testPointToPointPairCase struct {
+	prefix     string
+	a, b       string
+	shouldFail bool
+}
+
+func TestPointToPointPair(t *testing.T) {
+	testData := []testPointToPointPairCase{
+		{
+			prefix: "10.0.0.0/31",
+			a:      "10.0.0.0",
+			b:      "10.0.0.1",
+		},
+		{
+			prefix: "10.0.0.1/31",
+			a:      "10.0.0.0",
+			b:      "10.0.0.1",
+		},
+		{
+			prefix: "2001:dead::2/127",
+			a:      "2001:dead::2",
+			b:      "2001:dead::3",
+		},
+		{
+			prefix: "::ffff:10.0.0.0/127",
+			a:      "::ffff:10.0.0.0",
+			b:      "::ffff:10.0.0.1",
+		},
+		{
+			prefix:     "10.0.0.0/30",
+			shouldFail: true,
+		},
+		{
+			prefix:     "2001:dead::/64",
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testPointToPointPairCase) error {
+		a, b, err := PointToPointPair(netip.MustParsePrefix(c.prefix))
+		if err != nil {
+			return err
+		}
+		if a.String() != c.a || b.String() != c.b {
+			return fmt.Errorf("result %v,%v is different from expected %v,%v", a, b, c.a, c.b)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testContainedInAnyCase struct {
+	addr          string
+	prefixes      []string
+	expected      string
+	expectedFound bool
+}
+
+func TestContainedInAny(t *testing.T) {
+	testData := []testContainedInAnyCase{
+		{
+			addr:          "10.1.1.1",
+			prefixes:      []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.1.0/24", "10.1.2.0/24"},
+			expected:      "10.1.1.0/24",
+			expectedFound: true,
+		},
+		{
+			addr:          "10.1.1.1",
+			prefixes:      []string{"10.1.1.0/24", "10.1.0.0/16", "10.0.0.0/8"},
+			expected:      "10.1.1.0/24",
+			expectedFound: true,
+		},
+		{
+			addr:          "10.2.1.1",
+			prefixes:      []string{"10.1.1.0/24", "10.1.0.0/16", "10.0.0.0/8", "10.0.0.0/8"},
+			expected:      "10.0.0.0/8",
+			expectedFound: true,
+		},
+		{
+			addr:     "11.1.1.1",
+			prefixes: []string{"10.1.1.0/24", "10.0.0.0/8", "::/0"},
+		},
+		{
+			addr:          "2001:dead::1",
+			prefixes:      []string{"0.0.0.0/0", "::/0", "2001:dead::/32"},
+			expected:      "2001:dead::/32",
+			expectedFound: true,
+		},
+		{
+			addr:          "fe80::1%eth0",
+			prefixes:      []string{"2001:dead::/32", "fe80::/64"},
+			expected:      "fe80::/64",
+			expectedFound: true,
+		},
+	}
+	for i, c := range testData {
+		prefixes := []netip.Prefix{}
+		for _, p := range c.prefixes {
+			prefixes = append(prefixes, netip.MustParsePrefix(p))
+		}
+		r, found := ContainedInAny(netip.MustParseAddr(c.addr), prefixes)
+		if found != c.expectedFound || (found && r.String() != c.expected) {
+			t.Fatalf("case %d: result %v,%v is different from expected %v,%v", i, r, found, c.expected, c.expectedFound)
+		}
+	}
+}
+
+func TestGatewayAddr(t *testing.T) {
+	testData := []struct {
+		prefix     string
+		expected   string
+		shouldFail bool
+	}{
+		{"192.168.1.100/24", "192.168.1.1", false},
+		{"10.0.0.0/31", "10.0.0.1", false},
+		{"2001:dead:beef::/64", "2001:dead:beef::1", false},
+		{"::ffff:10.0.0.0/120", "::ffff:10.0.0.1", false},
+		{"10.0.0.1/32", "", true},
+		{"2001:dead::1/128", "", true},
+	}
+	for i, c := range testData {
+		r, err := GatewayAddr(netip.MustParsePrefix(c.prefix))
+		if (err != nil) != c.shouldFail {
+			t.Fatalf("case %d: unexpected error %v", i, err)
+		}
+		if err == nil && r.String() != c.expected {
+			t.Fatalf("case %d: result %v is different from expected %v", i, r, c.expected)
+		}
+	}
+}
+
+type testStepStaysInPrefixCase struct {
+	prefix     string
+	addr       string
+	step       int64
+	expected   bool
+	shouldFail bool
+}
+
+func TestStepStaysInPrefix(t *testing.T) {
+	testData := []testStepStaysInPrefixCase{
+		{prefix: "10.0.0.0/24", addr: "10.0.0.1", step: 100, expected: true},
+		//land exactly on broadcast address
+		{prefix: "10.0.0.0/24", addr: "10.0.0.1", step: 254, expected: true},
+		{prefix: "10.0.0.0/24", addr: "10.0.0.1", step: 255, expected: false},
+		{prefix: "10.0.0.0/24", addr: "10.0.0.1", step: -1, expected: true},
+		{prefix: "10.0.0.0/24", addr: "10.0.0.1", step: -2, expected: false},
+		{prefix: "10.0.0.0/24", addr: "10.0.1.1", step: -256, expected: true},
+		{prefix: "255.255.255.0/24", addr: "255.255.255.255", step: 1, expected: false},
+		{prefix: "2001:dead::/64", addr: "2001:dead::ffff:ffff:ffff:fffe%eth0", step: 1, expected: true},
+		{prefix: "2001:dead::/64", addr: "2001:dead::ffff:ffff:ffff:fffe", step: 2, expected: false},
+		{prefix: "10.0.0.0/24", addr: "::a00:1", step: 1, shouldFail: true},
+	}
+	runTest := func(c testStepStaysInPrefixCase) error {
+		r, err := StepStaysInPrefix(netip.MustParsePrefix(c.prefix), netip.MustParseAddr(c.addr), big.NewInt(c.step))
+		if err != nil {
+			return err
+		}
+		if r != c.expected {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Hu Jun. All rights reserved.
+// This project is licensed under the terms of the MIT license.
+// license that can be found in the LICENSE file.
+
+package myaddr
+
+import (
+	"net/netip"
+)
+
+// address scopes returned by AddrScope
+const (
+	ScopeInvalid       = "invalid"
+	ScopeUnspecified   = "unspecified"
+	ScopeLoopback      = "loopback"
+	ScopeLinkLocal     = "link-local"
+	ScopeMulticast     = "multicast"
+	ScopeBroadcast     = "broadcast"
+	ScopePrivate       = "private"
+	ScopeUniqueLocal   = "unique-local"
+	ScopeShared        = "shared"
+	ScopeDocumentation = "documentation"
+	ScopeBenchmarking  = "benchmarking"
+	ScopeReserved      = "reserved"
+	ScopeTranslation   = "translation"
+	ScopeGlobal        = "global"
+)
+
+// SpecialUseRange is a special-use address range and its scope
+type SpecialUseRange struct {
+	Prefix netip.Prefix
+	Scope  string
+}
+
+// specialUseRanges is the list of special-use address ranges used by AddrScope,
+// based on IANA IPv4/IPv6 special-purpose address registry;
+// it is checked in order and first match wins
+var specialUseRanges = []SpecialUseRange{
+	//IPv4
+	{netip.MustParsePrefix("0.0.0.0/32"), ScopeUnspecified},
+	{netip.MustParsePrefix("127.0.0.0/8"), ScopeLoopback},
+	{netip.MustParsePrefix("169.254.0.0/16"), ScopeLinkLocal},
+	{netip.MustParsePrefix("224.0.0.0/4"), ScopeMulticast},
+	{netip.MustParsePrefix("255.255.255.255/32"), ScopeBroadcast},
+	{netip.MustParsePrefix("10.0.0.0/8"), ScopePrivate},
+	{netip.MustParsePrefix("172.16.0.0/12"), ScopePrivate},
+	{netip.MustParsePrefix("192.168.0.0/16"), ScopePrivate},
+	{netip.MustParsePrefix("100.64.0.0/10"), ScopeShared},
+	{netip.MustParsePrefix("192.0.2.0/24"), ScopeDocumentation},
+	{netip.MustParsePrefix("198.51.100.0/24"), ScopeDocumentation},
+	{netip.MustParsePrefix("203.0.113.0/24"), ScopeDocumentation},
+	{netip.MustParsePrefix("198.18.0.0/15"), ScopeBenchmarking},
+	{netip.MustParsePrefix("192.0.0.0/24"), ScopeReserved},
+	{netip.MustParsePrefix("192.88.99.0/24"), ScopeReserved},
+	{netip.MustParsePrefix("0.0.0.0/8"), ScopeReserved},
+	{netip.MustParsePrefix("240.0.0.0/4"), ScopeReserved},
+	//IPv6
+	{netip.MustParsePrefix("::/128"), ScopeUnspecified},
+	{netip.MustParsePrefix("::1/128"), ScopeLoopback},
+	{netip.MustParsePrefix("fe80::/10"), ScopeLinkLocal},
+	{netip.MustParsePrefix("ff00::/8"), ScopeMulticast},
+	{netip.MustParsePrefix("fc00::/7"), ScopeUniqueLocal},
+	{netip.MustParsePrefix("2001:db8::/32"), ScopeDocumentation},
+	{netip.MustParsePrefix("2001:2::/48"), ScopeBenchmarking},
+	{netip.MustParsePrefix("64:ff9b::/96"), ScopeTranslation},
+	{netip.MustParsePrefix("100::/64"), ScopeReserved},
+	//after 2001:2::/48 which it contains
+	{netip.MustParsePrefix("2001::/23"), ScopeReserved},
+}
+
+// SpecialUseRanges return a copy of the special-use address ranges used by AddrScope,
+// in the order they are checked
+func SpecialUseRanges() []SpecialUseRange {
+	return append([]SpecialUseRange(nil), specialUseRanges...)
+}
+
+// AddrScope return the scope of addr, one of Scope* constants,
+// by checking addr against SpecialUseRanges(), ScopeGlobal is returned if there is no match.
+// an IPv4-mapped IPv6 address is classified as the IPv4 address it maps
+func AddrScope(addr netip.Addr) string {
+	if !addr.IsValid() {
+		return ScopeInvalid
+	}
+	addr = addr.Unmap().WithZone("")
+	for _, r := range specialUseRanges {
+		if r.Prefix.Contains(addr) {
+			return r.Scope
+		}
+	}
+	return ScopeGlobal
+}
@@ -0,0 +1,62 @@
+// myaddr_test
+package myaddr
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestAddrScope(t *testing.T) {
+	testData := []struct {
+		addr  netip.Addr
+		scope string
+	}{
+		{netip.Addr{}, ScopeInvalid},
+		{netip.MustParseAddr("0.0.0.0"), ScopeUnspecified},
+		{netip.MustParseAddr("0.1.2.3"), ScopeReserved},
+		{netip.MustParseAddr("127.0.0.1"), ScopeLoopback},
+		{netip.MustParseAddr("169.254.1.1"), ScopeLinkLocal},
+		{netip.MustParseAddr("224.0.0.5"), ScopeMulticast},
+		{netip.MustParseAddr("255.255.255.255"), ScopeBroadcast},
+		{netip.MustParseAddr("250.1.1.1"), ScopeReserved},
+		{netip.MustParseAddr("10.1.1.1"), ScopePrivate},
+		{netip.MustParseAddr("172.31.255.255"), ScopePrivate},
+		{netip.MustParseAddr("172.32.0.0"), ScopeGlobal},
+		{netip.MustParseAddr("192.168.1.1"), ScopePrivate},
+		{netip.MustParseAddr("100.64.0.1"), ScopeShared},
+		{netip.MustParseAddr("192.0.2.1"), ScopeDocumentation},
+		{netip.MustParseAddr("198.51.100.1"), ScopeDocumentation},
+		{netip.MustParseAddr("203.0.113.1"), ScopeDocumentation},
+		{netip.MustParseAddr("198.19.1.1"), ScopeBenchmarking},
+		{netip.MustParseAddr("8.8.8.8"), ScopeGlobal},
+		{netip.MustParseAddr("::ffff:10.1.1.1"), ScopePrivate},
+		{netip.MustParseAddr("::"), ScopeUnspecified},
+		{netip.MustParseAddr("::1"), ScopeLoopback},
+		{netip.MustParseAddr("fe80::1%eth0"), ScopeLinkLocal},
+		{netip.MustParseAddr("ff02::1"), ScopeMulticast},
+		{netip.MustParseAddr("fd00::1"), ScopeUniqueLocal},
+		{netip.MustParseAddr("2001:db8::1"), ScopeDocumentation},
+		{netip.MustParseAddr("2001:2::1"), ScopeBenchmarking},
+		{netip.MustParseAddr("2001:4860::8888"), ScopeGlobal},
+		{netip.MustParseAddr("192.0.0.9"), ScopeReserved},
+		{netip.MustParseAddr("192.88.99.1"), ScopeReserved},
+		{netip.MustParseAddr("64:ff9b::808:808"), ScopeTranslation},
+		{netip.MustParseAddr("2001::1"), ScopeReserved},
+		{netip.MustParseAddr("2001:1ff::1"), ScopeReserved},
+		{netip.MustParseAddr("2001:200::1"), ScopeGlobal},
+		{netip.MustParseAddr("100::1"), ScopeReserved},
+	}
+	for _, c := range testData {
+		if s := AddrScope(c.addr); s != c.scope {
+			t.Fatalf("scope of %v is %v, expected %v", c.addr, s, c.scope)
+		}
+	}
+	//modifying the returned copy doesn't affect AddrScope
+	ranges := SpecialUseRanges()
+	for i := range ranges {
+		ranges[i].Scope = ScopeGlobal
+	}
+	if s := AddrScope(netip.MustParseAddr("10.1.1.1")); s != ScopePrivate {
+		t.Fatalf("scope of 10.1.1.1 is %v after modifying SpecialUseRanges result", s)
+	}
+}
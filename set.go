@@ -0,0 +1,118 @@
+// Copyright 2020 Hu Jun. All rights reserved.
+// This project is licensed under the terms of the MIT license.
+// license that can be found in the LICENSE file.
+
+package myaddr
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"sort"
+)
+
+// AddrSet is a set of addresses, stored as sorted, non-overlapping address ranges,
+// so memory usage is proportional to the number of ranges instead of addresses;
+// IPv4 and IPv6 addresses are stored separately.
+// zero value is an empty AddrSet ready to use, it is not safe for concurrent use
+type AddrSet struct {
+	ranges4, ranges6 []bigRange
+}
+
+// rangesOf return pointer to the range list of family of addr
+func (set *AddrSet) rangesOf(addr netip.Addr) *[]bigRange {
+	if addr.Is4() {
+		return &set.ranges4
+	}
+	return &set.ranges6
+}
+
+// Add add all addresses of prefix into the set, host bits of prefix are ignored;
+// an invalid prefix is ignored
+func (set *AddrSet) Add(prefix netip.Prefix) {
+	if !prefix.IsValid() {
+		return
+	}
+	rs := set.rangesOf(prefix.Addr())
+	*rs = mergeRanges(append(*rs, prefixToBigRange(prefix)))
+}
+
+// Remove remove all addresses of prefix from the set, host bits of prefix are ignored;
+// an existing range is split if prefix is in the middle of it; an invalid prefix is ignored
+func (set *AddrSet) Remove(prefix netip.Prefix) {
+	if !prefix.IsValid() {
+		return
+	}
+	rs := set.rangesOf(prefix.Addr())
+	pr := prefixToBigRange(prefix)
+	r := []bigRange{}
+	for _, cur := range *rs {
+		if cur.end.Cmp(pr.start) < 0 || cur.start.Cmp(pr.end) > 0 {
+			r = append(r, cur)
+			continue
+		}
+		if cur.start.Cmp(pr.start) < 0 {
+			r = append(r, bigRange{start: cur.start, end: new(big.Int).Sub(pr.start, big.NewInt(1))})
+		}
+		if cur.end.Cmp(pr.end) > 0 {
+			r = append(r, bigRange{start: new(big.Int).Add(pr.end, big.NewInt(1)), end: cur.end})
+		}
+	}
+	*rs = r
+}
+
+// Contains return true if addr is in the set, zone of addr is ignored
+func (set *AddrSet) Contains(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	rs := *set.rangesOf(addr)
+	n := netipToBig(addr)
+	//first range ends at or after n
+	i := sort.Search(len(rs), func(i int) bool {
+		return rs[i].end.Cmp(n) >= 0
+	})
+	return i < len(rs) && rs[i].start.Cmp(n) <= 0
+}
+
+// Prefixes return the minimal list of prefixes covering the set,
+// IPv4 prefixes first, each family in ascending order
+func (set *AddrSet) Prefixes() []netip.Prefix {
+	//ranges in the set are always valid, so there is no error
+	r, _ := rangesToPrefixes(set.ranges4, 32)
+	r6, _ := rangesToPrefixes(set.ranges6, 128)
+	return append(r, r6...)
+}
+
+// prefixesMinus return minimal list of prefixes covering addresses in a but not in b
+func prefixesMinus(a, b []netip.Prefix) ([]netip.Prefix, error) {
+	var set AddrSet
+	for _, p := range a {
+		if !p.IsValid() {
+			return nil, fmt.Errorf("invalid prefix %v", p)
+		}
+		set.Add(p)
+	}
+	for _, p := range b {
+		if !p.IsValid() {
+			return nil, fmt.Errorf("invalid prefix %v", p)
+		}
+		set.Remove(p)
+	}
+	return set.Prefixes(), nil
+}
+
+// PrefixDiff return the minimal list of prefixes covering addresses in a but not in b as onlyA,
+// and the ones in b but not in a as onlyB, IPv4 prefixes first, each family in ascending order;
+// prefixes in a or b could overlap with each other, and could be in different address family
+func PrefixDiff(a, b []netip.Prefix) (onlyA, onlyB []netip.Prefix, err error) {
+	onlyA, err = prefixesMinus(a, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	onlyB, err = prefixesMinus(b, a)
+	if err != nil {
+		return nil, nil, err
+	}
+	return onlyA, onlyB, nil
+}
@@ -0,0 +1,178 @@
+// myaddr_test
+package myaddr
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+type testAddrSetCase struct {
+	add        []string
+	remove     []string
+	contains   []string
+	notContain []string
+	expected   []string
+	shouldFail bool
+}
+
+func TestAddrSet(t *testing.T) {
+	testData := []testAddrSetCase{
+		//adjacent prefixes are coalesced
+		{
+			add:        []string{"10.0.0.0/25", "10.0.0.128/25", "10.0.1.0/24"},
+			contains:   []string{"10.0.0.0", "10.0.1.255"},
+			notContain: []string{"10.0.2.0", "9.255.255.255", "::a00:0"},
+			expected:   []string{"10.0.0.0/23"},
+		},
+		//overlapping and non-masked prefixes
+		{
+			add:      []string{"10.0.0.0/16", "10.0.5.5/24", "192.168.1.0/24", "2001:dead::/32", "2001:dead:1::/48"},
+			contains: []string{"10.0.5.1", "192.168.1.1", "2001:dead:ffff::1"},
+			expected: []string{"10.0.0.0/16", "192.168.1.0/24", "2001:dead::/32"},
+		},
+		//remove splits a range
+		{
+			add:        []string{"10.0.0.0/24"},
+			remove:     []string{"10.0.0.64/26"},
+			contains:   []string{"10.0.0.63", "10.0.0.128"},
+			notContain: []string{"10.0.0.64", "10.0.0.127"},
+			expected:   []string{"10.0.0.0/26", "10.0.0.128/25"},
+		},
+		//remove across multiple ranges and other family
+		{
+			add:        []string{"10.0.0.0/24", "10.0.2.0/24", "2001:dead::/64"},
+			remove:     []string{"10.0.0.0/22", "2001:dead::/65"},
+			contains:   []string{"2001:dead::8000:0:0:0"},
+			notContain: []string{"10.0.0.1", "10.0.2.1", "2001:dead::1"},
+			expected:   []string{"2001:dead:0:0:8000::/65"},
+		},
+		{
+			add:      []string{"10.0.0.0/24"},
+			remove:   []string{"invalid"},
+			expected: []string{"10.0.0.0/24"},
+		},
+	}
+	runTest := func(c testAddrSetCase) error {
+		var set AddrSet
+		for _, p := range c.add {
+			set.Add(netip.MustParsePrefix(p))
+		}
+		for _, s := range c.remove {
+			//an invalid prefix is ignored
+			p, _ := netip.ParsePrefix(s)
+			set.Remove(p)
+		}
+		for _, s := range c.contains {
+			if !set.Contains(netip.MustParseAddr(s)) {
+				return fmt.Errorf("set should contain %v", s)
+			}
+		}
+		for _, s := range c.notContain {
+			if set.Contains(netip.MustParseAddr(s)) {
+				return fmt.Errorf("set should not contain %v", s)
+			}
+		}
+		r := set.Prefixes()
+		if len(r) != len(c.expected) {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+		}
+		for i, p := range r {
+			if p.String() != c.expected[i] {
+				return fmt.Errorf("result %v is different from expected %v", r, c.expected)
+			}
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testPrefixDiffCase struct {
+	a, b         []string
+	onlyA, onlyB []string
+	shouldFail   bool
+}
+
+func TestPrefixDiff(t *testing.T) {
+	testData := []testPrefixDiffCase{
+		{
+			a:     []string{"10.0.0.0/24", "10.0.1.0/24"},
+			b:     []string{"10.0.0.0/23"},
+			onlyA: []string{},
+			onlyB: []string{},
+		},
+		//nested
+		{
+			a:     []string{"10.0.0.0/16"},
+			b:     []string{"10.0.1.0/24", "10.0.128.0/17"},
+			onlyA: []string{"10.0.0.0/24", "10.0.2.0/23", "10.0.4.0/22", "10.0.8.0/21", "10.0.16.0/20", "10.0.32.0/19", "10.0.64.0/18"},
+			onlyB: []string{},
+		},
+		//overlapping, both sides have extra
+		{
+			a:     []string{"10.0.0.0/24", "10.0.0.0/25", "2001:dead::/48"},
+			b:     []string{"10.0.0.128/25", "10.0.1.0/24", "2001:dead::/47"},
+			onlyA: []string{"10.0.0.0/25"},
+			onlyB: []string{"10.0.1.0/24", "2001:dead:1::/48"},
+		},
+		{
+			a:          []string{"10.0.0.0/24"},
+			b:          []string{"invalid"},
+			shouldFail: true,
+		},
+	}
+	toPrefixes := func(l []string) []netip.Prefix {
+		r := make([]netip.Prefix, len(l))
+		for i, s := range l {
+			r[i], _ = netip.ParsePrefix(s)
+		}
+		return r
+	}
+	compare := func(r []netip.Prefix, expected []string) error {
+		if len(r) != len(expected) {
+			return fmt.Errorf("result %v is different from expected %v", r, expected)
+		}
+		for i, p := range r {
+			if p.String() != expected[i] {
+				return fmt.Errorf("result %v is different from expected %v", r, expected)
+			}
+		}
+		return nil
+	}
+	runTest := func(c testPrefixDiffCase) error {
+		onlyA, onlyB, err := PrefixDiff(toPrefixes(c.a), toPrefixes(c.b))
+		if err != nil {
+			return err
+		}
+		if err := compare(onlyA, c.onlyA); err != nil {
+			return fmt.Errorf("onlyA: %w", err)
+		}
+		if err := compare(onlyB, c.onlyB); err != nil {
+			return fmt.Errorf("onlyB: %w", err)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 Hu Jun. All rights reserved.
+// This project is licensed under the terms of the MIT license.
+// license that can be found in the LICENSE file.
+
+package myaddr
+
+import (
+	"net/netip"
+)
+
+type trieNode struct {
+	children [2]*trieNode
+	hasValue bool
+	prefix   netip.Prefix
+	value    any
+}
+
+// PrefixTrie is a binary radix tree of prefixes, used for longest prefix match lookup,
+// IPv4 and IPv6 prefixes are stored in separate trees;
+// zero value is an empty PrefixTrie ready to use, it is not safe for concurrent use
+type PrefixTrie struct {
+	root4, root6 *trieNode
+}
+
+// addrBit return the i-th bit of b, bit 0 is the most significant bit
+func addrBit(b []byte, i int) int {
+	return int(b[i/8]>>(7-i%8)) & 1
+}
+
+// Insert add prefix with associated value into the trie,
+// host bits of prefix are ignored; value of an existing prefix is replaced;
+// an invalid prefix is ignored
+func (trie *PrefixTrie) Insert(prefix netip.Prefix, value any) {
+	if !prefix.IsValid() {
+		return
+	}
+	prefix = prefix.Masked()
+	root := &trie.root6
+	if prefix.Addr().Is4() {
+		root = &trie.root4
+	}
+	if *root == nil {
+		*root = &trieNode{}
+	}
+	node := *root
+	b := prefix.Addr().AsSlice()
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := addrBit(b, i)
+		if node.children[bit] == nil {
+			node.children[bit] = &trieNode{}
+		}
+		node = node.children[bit]
+	}
+	node.hasValue = true
+	node.prefix = prefix
+	node.value = value
+}
+
+// LongestMatch return the longest prefix in the trie that contains addr,
+// along with its value, and true if there is one; zone of addr is ignored
+func (trie *PrefixTrie) LongestMatch(addr netip.Addr) (netip.Prefix, any, bool) {
+	node := trie.root6
+	if addr.Is4() {
+		node = trie.root4
+	}
+	if !addr.IsValid() || node == nil {
+		return netip.Prefix{}, nil, false
+	}
+	var match *trieNode
+	b := addr.AsSlice()
+	for i := 0; ; i++ {
+		if node.hasValue {
+			match = node
+		}
+		if i == addr.BitLen() {
+			break
+		}
+		node = node.children[addrBit(b, i)]
+		if node == nil {
+			break
+		}
+	}
+	if match == nil {
+		return netip.Prefix{}, nil, false
+	}
+	return match.prefix, match.value, true
+}
@@ -0,0 +1,109 @@
+// myaddr_test
+package myaddr
+
+import (
+	"net/netip"
+	"testing"
+)
+
+type testPrefixTrieCase struct {
+	addr           string
+	expectedPrefix string
+	expectedValue  any
+	expectedFound  bool
+}
+
+func TestPrefixTrie(t *testing.T) {
+	var trie PrefixTrie
+	if _, _, found := trie.LongestMatch(netip.MustParseAddr("10.1.1.1")); found {
+		t.Fatal("empty trie should not match")
+	}
+	entries := map[string]any{
+		"10.0.0.0/8":       "a",
+		"10.1.0.0/16":      "b",
+		"10.1.1.0/24":      "c",
+		"10.1.1.1/32":      "d",
+		"192.168.1.100/24": "e",
+		"::/0":             "f",
+		"2001:dead::/32":   "g",
+		"2001:dead::/48":   "h",
+	}
+	for p, v := range entries {
+		trie.Insert(netip.MustParsePrefix(p), v)
+	}
+	var empty PrefixTrie
+	empty.Insert(netip.Prefix{}, "x")
+	if empty.root4 != nil || empty.root6 != nil {
+		t.Fatal("invalid prefix should be ignored")
+	}
+	//replace value
+	trie.Insert(netip.MustParsePrefix("2001:dead::/48"), "i")
+	testData := []testPrefixTrieCase{
+		{
+			addr:           "10.1.1.1",
+			expectedPrefix: "10.1.1.1/32",
+			expectedValue:  "d",
+			expectedFound:  true,
+		},
+		{
+			addr:           "10.1.1.2",
+			expectedPrefix: "10.1.1.0/24",
+			expectedValue:  "c",
+			expectedFound:  true,
+		},
+		{
+			addr:           "10.1.2.2",
+			expectedPrefix: "10.1.0.0/16",
+			expectedValue:  "b",
+			expectedFound:  true,
+		},
+		{
+			addr:           "10.2.2.2",
+			expectedPrefix: "10.0.0.0/8",
+			expectedValue:  "a",
+			expectedFound:  true,
+		},
+		{
+			addr:           "192.168.1.1",
+			expectedPrefix: "192.168.1.0/24",
+			expectedValue:  "e",
+			expectedFound:  true,
+		},
+		{
+			addr: "11.1.1.1",
+		},
+		{
+			addr:           "2001:dead::1",
+			expectedPrefix: "2001:dead::/48",
+			expectedValue:  "i",
+			expectedFound:  true,
+		},
+		{
+			addr:           "2001:dead:1::1",
+			expectedPrefix: "2001:dead::/32",
+			expectedValue:  "g",
+			expectedFound:  true,
+		},
+		{
+			addr:           "fe80::1%eth0",
+			expectedPrefix: "::/0",
+			expectedValue:  "f",
+			expectedFound:  true,
+		},
+	}
+	for i, c := range testData {
+		p, v, found := trie.LongestMatch(netip.MustParseAddr(c.addr))
+		if found != c.expectedFound || (found && (p.String() != c.expectedPrefix || v != c.expectedValue)) {
+			t.Fatalf("case %d: result %v,%v,%v is different from expected %v,%v,%v", i, p, v, found, c.expectedPrefix, c.expectedValue, c.expectedFound)
+		}
+		//must be consistent with ContainedInAny
+		prefixes := []netip.Prefix{}
+		for p := range entries {
+			prefixes = append(prefixes, netip.MustParsePrefix(p).Masked())
+		}
+		lp, lfound := ContainedInAny(netip.MustParseAddr(c.addr).WithZone(""), prefixes)
+		if lfound != found || lp != p {
+			t.Fatalf("case %d: result %v,%v is different from ContainedInAny %v,%v", i, p, found, lp, lfound)
+		}
+	}
+}
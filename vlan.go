@@ -0,0 +1,226 @@
+// Copyright 2020 Hu Jun. All rights reserved.
+// This project is licensed under the terms of the MIT license.
+// license that can be found in the LICENSE file.
+
+package myaddr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrVLANOverflow is returned when increasing a VLAN stack requires more tags than it has
+var ErrVLANOverflow = errors.New("VLAN stack overflow")
+
+// packTags pack ids into a *big.Int, each tag is bitsPerTag long,
+// ids[0] is the most significant tag
+func packTags(ids []uint16, bitsPerTag int) (*big.Int, error) {
+	maxTag := uint64(1)<<bitsPerTag - 1
+	all := big.NewInt(0)
+	for _, id := range ids {
+		if uint64(id) > maxTag {
+			return nil, fmt.Errorf("invalid %d bit tag %d", bitsPerTag, id)
+		}
+		all.Lsh(all, uint(bitsPerTag))
+		all.Or(all, new(big.Int).SetUint64(uint64(id)))
+	}
+	return all, nil
+}
+
+// unpackTags is the reverse of packTags, return tagCount tags
+func unpackTags(n *big.Int, tagCount, bitsPerTag int) ([]uint16, error) {
+	if n.Sign() < 0 {
+		return nil, fmt.Errorf("%v is negative", n)
+	}
+	if n.BitLen() > tagCount*bitsPerTag {
+		return nil, fmt.Errorf("%v is too big for %d %d bit tags", n, tagCount, bitsPerTag)
+	}
+	all := new(big.Int).Set(n)
+	mask := new(big.Int).SetUint64(uint64(1)<<bitsPerTag - 1)
+	tag := new(big.Int)
+	r := make([]uint16, tagCount)
+	for i := tagCount - 1; i >= 0; i-- {
+		r[i] = uint16(tag.And(all, mask).Uint64())
+		all.Rsh(all, uint(bitsPerTag))
+	}
+	return r, nil
+}
+
+// IncreaseTags increase a slice of tags with specified step (could be negative),
+// each tag is bitsPerTag long, in range [1, 16], 0 means 12 (VLAN ID);
+// the slice is treated as a single number with ids[0] being the most significant tag,
+// carry goes into previous tag, and a new leading tag is added if ids[0] overflows;
+// the result has at least as many tags as ids.
+func IncreaseTags(ids []uint16, step int, bitsPerTag int) ([]uint16, error) {
+	if bitsPerTag == 0 {
+		bitsPerTag = 12
+	}
+	if bitsPerTag < 0 || bitsPerTag > 16 {
+		return []uint16{}, fmt.Errorf("invalid tag bit length %d", bitsPerTag)
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	all, err := packTags(ids, bitsPerTag)
+	if err != nil {
+		return []uint16{}, err
+	}
+	all.Add(all, big.NewInt(int64(step)))
+	if all.Sign() < 0 {
+		return []uint16{}, fmt.Errorf("%v and step %d result in negative result", ids, step)
+	}
+	n := (all.BitLen() + bitsPerTag - 1) / bitsPerTag
+	if n < len(ids) {
+		n = len(ids)
+	}
+	return unpackTags(all, n, bitsPerTag)
+}
+
+// IncreaseVLANIDsNoGrow is same as IncreaseVLANIDs, except it return ErrVLANOverflow
+// instead of adding a new leading tag when ids[0] overflows, so the result always has as many tags as ids
+func IncreaseVLANIDsNoGrow(ids []uint16, step int) ([]uint16, error) {
+	r, err := IncreaseVLANIDs(ids, step)
+	if err != nil {
+		return r, err
+	}
+	if len(r) > len(ids) {
+		return []uint16{}, fmt.Errorf("%v and step %d result requires more than %d VLAN tags, %w", ids, step, len(ids), ErrVLANOverflow)
+	}
+	return r, nil
+}
+
+// VLANStackToBig pack VLAN stack ids into a *big.Int, each VLAN ID takes 12 bits,
+// ids[0] is the most significant one, i.e. for a stack of n tags:
+// bit 12*(n-1) to bit 12*n-1 is ids[0], ..., bit 0 to bit 11 is ids[n-1]
+func VLANStackToBig(ids []uint16) (*big.Int, error) {
+	return packTags(ids, 12)
+}
+
+// BigToVLANStack is the reverse of VLANStackToBig, return a VLAN stack of tagCount tags,
+// return error if n doesn't fit into tagCount tags
+func BigToVLANStack(n *big.Int, tagCount int) ([]uint16, error) {
+	if tagCount <= 0 {
+		return nil, fmt.Errorf("invalid tag count %d", tagCount)
+	}
+	return unpackTags(n, tagCount, 12)
+}
+
+// MaxVLANStackIndexDepth is the max number of tags VLANStackIndex could pack into a uint64
+const MaxVLANStackIndexDepth = 5
+
+// VLANStackIndex pack VLAN stack ids into a uint64 like VLANStackToBig, each VLAN ID takes 12 bits,
+// without using big.Int; return error if ids has more than MaxVLANStackIndexDepth tags
+func VLANStackIndex(ids []uint16) (uint64, error) {
+	if len(ids) > MaxVLANStackIndexDepth {
+		return 0, fmt.Errorf("%v has more than %d VLAN tags", ids, MaxVLANStackIndexDepth)
+	}
+	var r uint64
+	for _, id := range ids {
+		if id > 0xfff {
+			return 0, fmt.Errorf("invalid VLAN id %d", id)
+		}
+		r = r<<12 | uint64(id)
+	}
+	return r, nil
+}
+
+// VLANStackFromIndex is the reverse of VLANStackIndex, return a VLAN stack of depth tags,
+// depth must be in range [1, MaxVLANStackIndexDepth], return error if idx doesn't fit into depth tags
+func VLANStackFromIndex(idx uint64, depth int) ([]uint16, error) {
+	if depth <= 0 || depth > MaxVLANStackIndexDepth {
+		return nil, fmt.Errorf("invalid tag count %d", depth)
+	}
+	if idx>>(12*uint(depth)) != 0 {
+		return nil, fmt.Errorf("%d is too big for %d VLAN tags", idx, depth)
+	}
+	r := make([]uint16, depth)
+	for i := depth - 1; i >= 0; i-- {
+		r[i] = uint16(idx & 0xfff)
+		idx >>= 12
+	}
+	return r, nil
+}
+
+// GenVLANStacks return count VLAN stacks, starting from start, each is step (could be negative)
+// apart from previous one, see IncreaseVLANIDs for how a stack is increased.
+// every returned stack has same number of tags as start,
+// return error wrapping ErrVLANOverflow if a carry requires more tags than start has, or error if result is negative
+func GenVLANStacks(start []uint16, count, step int) ([][]uint16, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("count %d is negative", count)
+	}
+	r := make([][]uint16, 0, count)
+	if count == 0 {
+		return r, nil
+	}
+	if len(start) == 0 {
+		return nil, fmt.Errorf("start VLAN stack is empty")
+	}
+	cur := start
+	for i := 0; i < count; i++ {
+		var delta int
+		if i > 0 {
+			delta = step
+		}
+		next, err := IncreaseVLANIDsNoGrow(cur, delta)
+		if err != nil {
+			return nil, err
+		}
+		cur = next
+		r = append(r, cur)
+	}
+	return r, nil
+}
+
+// VLANStack is a stack of VLAN IDs, VLANStack[0] is the outermost tag,
+// its JSON form is a string of dot separated VLAN IDs like "100.200"
+type VLANStack []uint16
+
+// String return dot separated VLAN IDs like "100.200"
+func (stack VLANStack) String() string {
+	strs := make([]string, len(stack))
+	for i, id := range stack {
+		strs[i] = strconv.Itoa(int(id))
+	}
+	return strings.Join(strs, ".")
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (stack VLANStack) MarshalJSON() ([]byte, error) {
+	for _, id := range stack {
+		if id > 0xfff {
+			return nil, fmt.Errorf("invalid VLAN id %d", id)
+		}
+	}
+	return json.Marshal(stack.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface
+func (stack *VLANStack) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	r := VLANStack{}
+	if s != "" {
+		for _, idstr := range strings.Split(s, ".") {
+			id, err := strconv.ParseUint(idstr, 10, 16)
+			if err != nil || id > 0xfff {
+				return fmt.Errorf("invalid VLAN id %v in %v", idstr, s)
+			}
+			r = append(r, uint16(id))
+		}
+	}
+	*stack = r
+	return nil
+}
+
+// IncreaseVLANStack is same as IncreaseVLANIDs, but takes and returns a VLANStack
+func IncreaseVLANStack(s VLANStack, step int) (VLANStack, error) {
+	r, err := IncreaseVLANIDs(s, step)
+	return VLANStack(r), err
+}
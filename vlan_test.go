@@ -0,0 +1,522 @@
+// myaddr_test
+package myaddr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+type testGenVLANStacksCase struct {
+	start          []uint16
+	count          int
+	step           int
+	expectedResult [][]uint16
+	shouldFail     bool
+}
+
+func TestGenVLANStacks(t *testing.T) {
+	testData := []testGenVLANStacksCase{
+		{
+			start:          []uint16{100, 4094},
+			count:          3,
+			step:           1,
+			expectedResult: [][]uint16{{100, 4094}, {100, 4095}, {101, 0}},
+		},
+		{
+			start:          []uint16{0, 4095},
+			count:          2,
+			step:           1,
+			expectedResult: [][]uint16{{0, 4095}, {1, 0}},
+		},
+		{
+			start:          []uint16{0, 10},
+			count:          3,
+			step:           -5,
+			expectedResult: [][]uint16{{0, 10}, {0, 5}, {0, 0}},
+		},
+		{
+			start:          []uint16{100},
+			count:          0,
+			step:           1,
+			expectedResult: [][]uint16{},
+		},
+		{
+			start:      []uint16{4095, 4095},
+			count:      2,
+			step:       1,
+			shouldFail: true,
+		},
+		{
+			start:      []uint16{0, 1},
+			count:      2,
+			step:       -2,
+			shouldFail: true,
+		},
+		{
+			start:      []uint16{5000},
+			count:      1,
+			step:       1,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testGenVLANStacksCase) error {
+		r, err := GenVLANStacks(c.start, c.count, c.step)
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(r) != fmt.Sprint(c.expectedResult) {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedResult)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testIncreaseTagsCase struct {
+	ids            []uint16
+	step           int
+	bitsPerTag     int
+	expectedResult []uint16
+	shouldFail     bool
+}
+
+func TestIncreaseTags(t *testing.T) {
+	testData := []testIncreaseTagsCase{
+		{
+			ids:            []uint16{100, 4095},
+			step:           2,
+			expectedResult: []uint16{101, 1},
+		},
+		{
+			ids:            []uint16{1, 15},
+			step:           1,
+			bitsPerTag:     4,
+			expectedResult: []uint16{2, 0},
+		},
+		{
+			ids:            []uint16{15, 15},
+			step:           1,
+			bitsPerTag:     4,
+			expectedResult: []uint16{1, 0, 0},
+		},
+		{
+			ids:            []uint16{1, 0xffff},
+			step:           1,
+			bitsPerTag:     16,
+			expectedResult: []uint16{2, 0},
+		},
+		{
+			ids:            []uint16{2, 0},
+			step:           -1,
+			bitsPerTag:     16,
+			expectedResult: []uint16{1, 0xffff},
+		},
+		{
+			ids:        []uint16{1, 16},
+			step:       1,
+			bitsPerTag: 4,
+			shouldFail: true,
+		},
+		{
+			ids:        []uint16{1, 1},
+			step:       1,
+			bitsPerTag: 17,
+			shouldFail: true,
+		},
+		{
+			ids:        []uint16{0, 1},
+			step:       -2,
+			bitsPerTag: 8,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testIncreaseTagsCase) error {
+		r, err := IncreaseTags(c.ids, c.step, c.bitsPerTag)
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(r) != fmt.Sprint(c.expectedResult) {
+			return fmt.Errorf("result %v is different from expected %v", r, c.expectedResult)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+type testVLANStackBigCase struct {
+	ids        []uint16
+	expectedN  int64
+	shouldFail bool
+}
+
+func TestVLANStackBig(t *testing.T) {
+	testData := []testVLANStackBigCase{
+		{
+			ids:       []uint16{100},
+			expectedN: 100,
+		},
+		{
+			ids:       []uint16{1, 2},
+			expectedN: 1<<12 + 2,
+		},
+		{
+			ids:       []uint16{0, 4095, 1},
+			expectedN: 4095<<12 + 1,
+		},
+		{
+			ids:       []uint16{4095, 4095},
+			expectedN: 1<<24 - 1,
+		},
+		{
+			ids:        []uint16{4096, 1},
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testVLANStackBigCase) error {
+		n, err := VLANStackToBig(c.ids)
+		if err != nil {
+			return err
+		}
+		if n.Int64() != c.expectedN {
+			return fmt.Errorf("result %v is different from expected %v", n, c.expectedN)
+		}
+		ids, err := BigToVLANStack(n, len(c.ids))
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(c.ids) {
+			return fmt.Errorf("converted back stack %v is different from original %v", ids, c.ids)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if _, err := BigToVLANStack(big.NewInt(1<<24), 2); err == nil {
+		t.Fatal("2^24 should not fit into 2 VLAN tags")
+	}
+	if _, err := BigToVLANStack(big.NewInt(-1), 2); err == nil {
+		t.Fatal("negative number should fail")
+	}
+}
+
+// increaseVLANIDsHex is the previous hex string based implementation of IncreaseVLANIDs,
+// used as reference in TestIncreaseVLANIDsAgainstHex and BenchmarkIncreaseVLANIDsHex
+func increaseVLANIDsHex(ids []uint16, step int) ([]uint16, error) {
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	bigstr := ""
+	for i := 0; i < len(ids); i++ {
+		if ids[i] > 0xfff {
+			return []uint16{}, fmt.Errorf("invalid VLAN id %d", ids[i])
+		}
+		s := big.NewInt(int64(ids[i])).Text(16)
+		for i := 0; i < len(s)%3; i++ {
+			s = "0" + s
+		}
+		bigstr += s
+	}
+	all := big.NewInt(0)
+	if _, ok := all.SetString(bigstr, 16); !ok {
+		return []uint16{}, fmt.Errorf("failed to increase, possible invaliud VLAN IDs %v", ids)
+	}
+	all.Add(all, big.NewInt(int64(step)))
+	newbigstr := all.Text(16)
+	for i := 0; i < len(newbigstr)%3; i++ {
+		newbigstr = "0" + newbigstr
+	}
+	r := []uint16{}
+	for i := 0; i < len(newbigstr); i += 3 {
+		newv := big.NewInt(0)
+		if _, ok := newv.SetString(newbigstr[i:i+3], 16); !ok {
+			return []uint16{}, fmt.Errorf("failed conver a hex str to int, %v", newbigstr[i:i+3])
+		}
+		r = append(r, uint16(newv.Int64()))
+	}
+	return r, nil
+}
+
+func TestIncreaseVLANIDsAgainstHex(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		ids := make([]uint16, 1+rng.Intn(4))
+		for j := range ids {
+			ids[j] = uint16(rng.Intn(4096))
+		}
+		step := rng.Intn(20000) - 10000
+		r, err := IncreaseVLANIDs(ids, step)
+		n, _ := VLANStackToBig(ids)
+		if n.Add(n, big.NewInt(int64(step))).Sign() < 0 {
+			//reference implementation returns garbage for negative result
+			if err == nil {
+				t.Fatalf("%v + %d: negative result should fail", ids, step)
+			}
+			continue
+		}
+		hexr, hexerr := increaseVLANIDsHex(ids, step)
+		if (err != nil) != (hexerr != nil) {
+			t.Fatalf("%v + %d: error %v is different from reference error %v", ids, step, err, hexerr)
+		}
+		if err != nil {
+			continue
+		}
+		//reference implementation drops leading zero tags
+		if len(hexr) > len(r) {
+			t.Fatalf("%v + %d: result %v is shorter than reference %v", ids, step, r, hexr)
+		}
+		padded := make([]uint16, len(r))
+		copy(padded[len(r)-len(hexr):], hexr)
+		if fmt.Sprint(r) != fmt.Sprint(padded) {
+			t.Fatalf("%v + %d: result %v is different from reference %v", ids, step, r, hexr)
+		}
+	}
+	r, err := IncreaseVLANIDs([]uint16{0, 5}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(r) != fmt.Sprint([]uint16{0, 6}) {
+		t.Fatalf("leading zero tag is not preserved, got %v", r)
+	}
+}
+
+func BenchmarkIncreaseVLANIDs(b *testing.B) {
+	ids := []uint16{100, 200, 4000}
+	for i := 0; i < b.N; i++ {
+		if _, err := IncreaseVLANIDs(ids, 100); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIncreaseVLANIDsHex(b *testing.B) {
+	ids := []uint16{100, 200, 4000}
+	for i := 0; i < b.N; i++ {
+		if _, err := increaseVLANIDsHex(ids, 100); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type testVLANStackJSONCase struct {
+	jsonStr    string
+	expected   VLANStack
+	shouldFail bool
+}
+
+func TestVLANStackJSON(t *testing.T) {
+	testData := []testVLANStackJSONCase{
+		{
+			jsonStr:  `"100.200"`,
+			expected: VLANStack{100, 200},
+		},
+		{
+			jsonStr:  `"4095"`,
+			expected: VLANStack{4095},
+		},
+		{
+			jsonStr:  `""`,
+			expected: VLANStack{},
+		},
+		{
+			jsonStr:    `"100.4096"`,
+			shouldFail: true,
+		},
+		{
+			jsonStr:    `"100..200"`,
+			shouldFail: true,
+		},
+		{
+			jsonStr:    `100`,
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testVLANStackJSONCase) error {
+		var stack VLANStack
+		if err := json.Unmarshal([]byte(c.jsonStr), &stack); err != nil {
+			return err
+		}
+		if fmt.Sprint(stack) != fmt.Sprint(c.expected) {
+			return fmt.Errorf("result %v is different from expected %v", stack, c.expected)
+		}
+		b, err := json.Marshal(stack)
+		if err != nil {
+			return err
+		}
+		if string(b) != c.jsonStr {
+			return fmt.Errorf("marshaled result %v is different from original %v", string(b), c.jsonStr)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+}
+
+func TestIncreaseVLANIDsNoGrow(t *testing.T) {
+	r, err := IncreaseVLANIDsNoGrow([]uint16{4095, 4095}, 2)
+	if !errors.Is(err, ErrVLANOverflow) {
+		t.Fatalf("expect ErrVLANOverflow, got %v and %v", r, err)
+	}
+	//IncreaseVLANIDs grows the stack for the same input
+	r, err = IncreaseVLANIDs([]uint16{4095, 4095}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(r) != "[1 0 1]" {
+		t.Fatalf("result %v is different from expected [1 0 1]", r)
+	}
+	r, err = IncreaseVLANIDsNoGrow([]uint16{4094, 4095}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(r) != "[4095 1]" {
+		t.Fatalf("result %v is different from expected [4095 1]", r)
+	}
+	if _, err = IncreaseVLANIDsNoGrow([]uint16{0, 1}, -2); err == nil || errors.Is(err, ErrVLANOverflow) {
+		t.Fatalf("expect negative result error, got %v", err)
+	}
+}
+
+type testVLANStackIndexCase struct {
+	ids        []uint16
+	expected   uint64
+	shouldFail bool
+}
+
+func TestVLANStackIndex(t *testing.T) {
+	testData := []testVLANStackIndexCase{
+		{
+			ids:      []uint16{100},
+			expected: 100,
+		},
+		{
+			ids:      []uint16{1, 2},
+			expected: 4098,
+		},
+		{
+			ids:      []uint16{4095, 4095, 4095, 4095, 4095},
+			expected: 1<<60 - 1,
+		},
+		{
+			ids:        []uint16{1, 2, 3, 4, 5, 6},
+			shouldFail: true,
+		},
+		{
+			ids:        []uint16{4096},
+			shouldFail: true,
+		},
+	}
+	runTest := func(c testVLANStackIndexCase) error {
+		idx, err := VLANStackIndex(c.ids)
+		if err != nil {
+			return err
+		}
+		if idx != c.expected {
+			return fmt.Errorf("index %d is different from expected %d", idx, c.expected)
+		}
+		n, err := VLANStackToBig(c.ids)
+		if err != nil {
+			return err
+		}
+		if n.Uint64() != idx {
+			return fmt.Errorf("index %d is different from VLANStackToBig result %v", idx, n)
+		}
+		ids, err := VLANStackFromIndex(idx, len(c.ids))
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(c.ids) {
+			return fmt.Errorf("VLANStackFromIndex result %v is different from %v", ids, c.ids)
+		}
+		return nil
+	}
+	for i, c := range testData {
+		err := runTest(c)
+		if err != nil {
+			if c.shouldFail {
+				t.Logf("expected case %d failed,%v ", i, err)
+			} else {
+				t.Fatal(err)
+			}
+		} else if c.shouldFail {
+			t.Fatalf("case %d should fail but succeed", i)
+		}
+	}
+	if _, err := VLANStackFromIndex(4096, 1); err == nil {
+		t.Fatal("4096 should not fit into 1 VLAN tag")
+	}
+	if _, err := VLANStackFromIndex(1, 6); err == nil {
+		t.Fatal("depth 6 should fail")
+	}
+	ids, err := VLANStackFromIndex(5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[0 0 5]" {
+		t.Fatalf("result %v is different from expected [0 0 5]", ids)
+	}
+}
+
+func TestIncreaseVLANStack(t *testing.T) {
+	if s := (VLANStack{}).String(); s != "" {
+		t.Fatalf("empty stack string %q is not empty", s)
+	}
+	r, err := IncreaseVLANStack(VLANStack{100, 4095}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "101.1" {
+		t.Fatalf("result %v is different from expected 101.1", r)
+	}
+	if fmt.Sprintf("%v", r) != "101.1" {
+		t.Fatalf("formatted result %v is different from expected 101.1", r)
+	}
+	if _, err := IncreaseVLANStack(VLANStack{0}, -1); err == nil {
+		t.Fatal("negative result should fail")
+	}
+}